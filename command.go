@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os/exec"
+	"time"
+
 	"github.com/elboletaire/remuxing/models"
 )
 
@@ -14,7 +16,11 @@ func CommandArguments(
 	video *models.TrackController,
 	audios models.Tracks,
 	subtitles models.Tracks,
-) (command []string) {
+) (command []string, err error) {
+	if video == nil {
+		return nil, &models.NoVideoTrackError{}
+	}
+
 	// The output line "-o {.filename}"
 	command = []string{"-o", output}
 	// Video options
@@ -24,15 +30,25 @@ func CommandArguments(
 	// Subtitles options
 	command = subtitlesString(subtitles, command)
 
-	return command
+	return command, nil
 }
 
 /*
-Command executes the mkvmerge system command with the given args
+Command executes the mkvmerge system command with the given args, returning a
+*MuxError if it fails
 */
 func Command(args []string) (result []byte, err error) {
 	result, err = exec.Command("mkvmerge", args...).CombinedOutput()
 
+	// mkvmerge exits with 1 when there were warnings, but the file was created
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == 1 {
+		return result, nil
+	}
+
+	if err != nil {
+		return result, &MuxError{Err: err, Output: result}
+	}
+
 	return
 }
 
@@ -49,29 +65,50 @@ func videoString(video *models.TrackController, command []string) []string {
 		"-S",
 		// Specify video id to be copied
 		"-d", video.Track.GetID(),
-		// Video route
-		video.Input.FileName,
 	)
 
+	if isDefault := video.Track.OutputDefault; isDefault != nil {
+		command = append(command, "--default-track", video.Track.GetArgIDLabel(fmt.Sprint(*isDefault)))
+	}
+
+	if video.Track.OutputName != "" {
+		command = append(command, "--track-name", video.Track.GetArgIDLabel(video.Track.OutputName))
+	}
+
+	// Video route
+	command = append(command, video.Input.FileName)
+
 	return command
 }
 
 func audiosString(audios models.Tracks, command []string) []string {
 	for i, audio := range audios {
 		command = append(command, "-T")
-		// Hardcode first as default (they should come already sorted by priority)
-		if i == 0 {
+		// Hardcode first as default (they should come already sorted by priority),
+		// unless told otherwise
+		isDefault := i == 0
+		if audio.Track.OutputDefault != nil {
+			isDefault = *audio.Track.OutputDefault
+		}
+		if isDefault {
 			command = append(command, "--default-track", audio.Track.GetID())
+		} else {
+			command = append(command, "--default-track", audio.Track.GetArgIDLabel("no"))
 		}
 
+		// Delay (or advance) it when it's not in sync with the video
+		command = append(command, syncArguments(audio)...)
+		// Commentary and audio description flags
+		command = append(command, kindArguments(audio)...)
+
 		// Ensure audio stream has language set
 		command = append(
 			command,
-			"--language", audio.Track.GetArgIDLabel(audio.Track.Properties.Language),
+			"--language", audio.Track.GetArgIDLabel(audio.Track.GetLanguage()),
 			// Copy this audio stream
 			"-a", audio.Track.GetID(),
-			// Remove its file name
-			"--track-name", audio.Track.GetArgID(),
+			// Remove its file name (or rename it)
+			"--track-name", audio.Track.GetArgIDLabel(audio.Track.OutputName),
 			// Do not copy videos from this file
 			"-D",
 			// Do not copy subtitles from this file
@@ -91,22 +128,75 @@ func subtitlesString(subtitles models.Tracks, command []string) []string {
 			"-T",
 			// Copy this subtitle track
 			"-s", fmt.Sprint(subtitle.Track.ID),
-			// Remove its file name
-			"--track-name", subtitle.Track.GetArgID(),
+			// Remove its file name (or rename it)
+			"--track-name", subtitle.Track.GetArgIDLabel(subtitle.Track.OutputName),
+			// Ensure subtitle stream has language set (sidecar files have none)
+			"--language", subtitle.Track.GetArgIDLabel(subtitle.Track.GetLanguage()),
 			// Do not copy audios nor videos from this track
 			"-D", "-A",
 		)
 
-		if subtitle.Track.Properties.Forced {
+		// Flags cleared by hand must be set, or the source one is kept
+		if isForced := subtitle.Track.OutputForced; isForced != nil {
+			command = append(
+				command,
+				"--forced-track", subtitle.Track.GetArgIDLabel(fmt.Sprint(*isForced)),
+			)
+		} else if subtitle.Track.Properties.Forced {
 			command = append(
 				command,
 				"--forced-track", subtitle.Track.GetArgIDLabel("true"),
 			)
 		}
 
+		if isDefault := subtitle.Track.OutputDefault; isDefault != nil {
+			command = append(
+				command,
+				"--default-track", subtitle.Track.GetArgIDLabel(fmt.Sprint(*isDefault)),
+			)
+		}
+
+		command = append(command, kindArguments(subtitle)...)
+
+		command = append(command, syncArguments(subtitle)...)
+
 		// The subtitle file source
 		command = append(command, subtitle.Input.FileName)
 	}
 
 	return command
 }
+
+// kindArguments returns the Matroska flag of the track kind (tracks whose kind
+// was taken from their name may not have it)
+func kindArguments(track models.TrackController) []string {
+	switch track.Track.Kind() {
+	case models.KindCommentary:
+		return []string{"--commentary-flag", track.Track.GetArgIDLabel("1")}
+	case models.KindDescription:
+		return []string{"--visual-impaired-flag", track.Track.GetArgIDLabel("1")}
+	case models.KindSDH:
+		return []string{"--hearing-impaired-flag", track.Track.GetArgIDLabel("1")}
+	}
+
+	return nil
+}
+
+// syncArguments returns the --sync option for the tracks of inputs with a
+// detected offset or a different frame rate
+func syncArguments(track models.TrackController) []string {
+	if track.Input == nil || track.Input.Sync == nil {
+		return nil
+	}
+
+	sync := track.Input.Sync
+	value := fmt.Sprint(int64(sync.Offset / time.Millisecond))
+	// Stretch factor, like 25025/24000 for PAL speed-ups
+	if numerator, denominator := sync.GetFactor(); numerator != denominator {
+		value += fmt.Sprintf(",%d/%d", numerator, denominator)
+	} else if sync.Offset == 0 {
+		return nil
+	}
+
+	return []string{"--sync", track.Track.GetArgIDLabel(value)}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elboletaire/remuxing/models"
+)
+
+// Exit codes, documented in the readme
+const (
+	exitError = iota + 1
+	exitInputNotFound
+	exitProbeFailed
+	exitUnsupportedContainer
+	exitNoVideoTrack
+	exitLanguageNotFound
+	exitMuxFailed
+	exitDurationMismatch
+	exitAudioRoleNotFound
+)
+
+/*
+MuxError is returned when mkvmerge fails to generate the output file
+*/
+type MuxError struct {
+	Err    error
+	Output []byte
+}
+
+func (err *MuxError) Error() string {
+	return fmt.Sprintf("mkvmerge failed (%v):\n%s", err.Err, strings.TrimSpace(string(err.Output)))
+}
+
+// exitCode returns the exit code for the given error.
+func exitCode(err error) int {
+	switch e := err.(type) {
+	case models.InputErrors:
+		// The first failing input determines the exit code
+		if len(e) > 0 {
+			return exitCode(e[0])
+		}
+	case *models.InputNotFoundError:
+		return exitInputNotFound
+	case *models.ProbeError:
+		return exitProbeFailed
+	case *models.UnsupportedContainerError:
+		return exitUnsupportedContainer
+	case *models.NoVideoTrackError:
+		return exitNoVideoTrack
+	case *models.LanguageNotFoundError:
+		return exitLanguageNotFound
+	case *models.AudioRoleNotFoundError:
+		return exitAudioRoleNotFound
+	case *models.DurationMismatchError:
+		return exitDurationMismatch
+	case *MuxError:
+		return exitMuxFailed
+	}
+
+	return exitError
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/elboletaire/remuxing/models"
+)
+
+// explainReport collects the decisions of every remux for -explain-json
+type explainReport struct {
+	path string
+	jobs []explainJob
+}
+
+type explainJob struct {
+	Output    string            `json:"output"`
+	Decisions []models.Decision `json:"decisions"`
+}
+
+func (report *explainReport) add(output string, decisions []models.Decision) {
+	report.jobs = append(report.jobs, explainJob{Output: output, Decisions: decisions})
+}
+
+// write writes the report to its file, or to the standard output when the
+// path is "-"
+func (report *explainReport) write() error {
+	jobs := report.jobs
+	if jobs == nil {
+		jobs = []explainJob{}
+	}
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if report.path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return ioutil.WriteFile(report.path, data, 0644)
+}
@@ -0,0 +1,32 @@
+package main
+
+import "github.com/elboletaire/remuxing/models"
+
+// trackFilter is the -video, -audio and -subs flags value
+type trackFilter struct {
+	filter *models.TrackFilter
+}
+
+func (filter *trackFilter) String() string {
+	if filter.filter == nil {
+		return ""
+	}
+
+	return filter.filter.String()
+}
+
+func (filter *trackFilter) Set(value string) (err error) {
+	filter.filter, err = models.ParseTrackFilter(value)
+
+	return
+}
+
+// apply returns the tracks matching the filter, or all of them when no filter
+// was given
+func (filter trackFilter) apply(tracks models.Tracks) models.Tracks {
+	if filter.filter == nil {
+		return tracks
+	}
+
+	return tracks.Filter(filter.filter.Match)
+}
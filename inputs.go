@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elboletaire/remuxing/models"
+)
+
+// Extensions of the files collected from directories and glob patterns
+var mediaExtensions = map[string]bool{
+	".mkv": true, ".mka": true, ".mks": true, ".mk3d": true, ".webm": true,
+	".mp4": true, ".m4v": true, ".m4a": true, ".mov": true, ".avi": true,
+	".ts": true, ".m2ts": true, ".mpg": true, ".vob": true,
+	".srt": true, ".ass": true, ".ssa": true, ".vtt": true, ".sup": true, ".idx": true,
+	".ac3": true, ".eac3": true, ".dts": true, ".thd": true, ".flac": true,
+	".aac": true, ".opus": true, ".ogg": true, ".mp3": true, ".wav": true,
+}
+
+var (
+	// S01E02, s1e2, S01.E02
+	seasonEpisodeRegexp = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])s(\d{1,2})[ ._-]?e(\d{1,3})(?:[^0-9]|$)`)
+	// 1x02
+	crossEpisodeRegexp = regexp.MustCompile(`(?i)(?:^|[^0-9])(\d{1,2})x(\d{2,3})(?:[^0-9]|$)`)
+	// ep 02, episode 2, E02
+	episodeRegexp = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:episode|ep|e)[ ._-]?(\d{1,3})(?:[^0-9]|$)`)
+	// Season 1, Temporada 2, S01 (used by directories)
+	seasonRegexp = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:season|temporada|saison|staffel|s)[ ._-]?(\d{1,2})(?:[^0-9]|$)`)
+)
+
+// remuxJob is a single mkvmerge run, with its own output and inputs.
+type remuxJob struct {
+	episode string
+	output  string
+	inputs  []string
+	// The argument (starting at 1) each input comes from, or 0 for the
+	// discovered sidecar files
+	arguments []int
+	sidecars  map[string]sidecarHint
+}
+
+// inputSource is each one of the inputs given as arguments, which may be
+// expanded to multiple files when it's a directory or a glob pattern.
+type inputSource struct {
+	files    []string
+	expanded bool
+}
+
+// buildJobs expands directories and glob patterns, grouping their files by
+// episode. Plain file inputs without episode identifiers are used by all the
+// jobs.
+func buildJobs(output string, args []string) (jobs []remuxJob, err error) {
+	var sources []inputSource
+	expanded := false
+
+	for _, arg := range args {
+		source, err := expandInput(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded = expanded || source.expanded
+		sources = append(sources, source)
+	}
+
+	// Nothing to group, everything goes to the given output
+	if !expanded {
+		job := remuxJob{output: output, inputs: args}
+		for i := range args {
+			job.arguments = append(job.arguments, i+1)
+		}
+
+		return []remuxJob{job}, nil
+	}
+
+	episodes := map[string][][]string{}
+	shared := make([][]string, len(sources))
+	for i, source := range sources {
+		for _, file := range source.files {
+			episode, ok := episodeID(file)
+			if !ok {
+				// Files without episode found in directories are not used
+				if !source.expanded {
+					shared[i] = append(shared[i], file)
+				}
+				continue
+			}
+
+			if episodes[episode] == nil {
+				episodes[episode] = make([][]string, len(sources))
+			}
+			episodes[episode][i] = append(episodes[episode][i], file)
+		}
+	}
+
+	if len(episodes) == 0 {
+		return nil, fmt.Errorf("no episodes found in the given inputs")
+	}
+
+	var ids []string
+	for id := range episodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		job := remuxJob{episode: id}
+		// Inputs priority is kept, as the sources are in the arguments order
+		for i, files := range episodes[id] {
+			for _, file := range append(shared[i], files...) {
+				job.inputs = append(job.inputs, file)
+				job.arguments = append(job.arguments, i+1)
+			}
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, setJobsOutput(output, jobs)
+}
+
+// setJobsOutput derives the output of each job when there's more than one, in
+// which case the given output must be a directory.
+func setJobsOutput(output string, jobs []remuxJob) error {
+	if len(jobs) == 1 && !isDir(output) {
+		jobs[0].output = output
+		return nil
+	}
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return fmt.Errorf("-output must be a directory when remuxing multiple episodes: %v", err)
+	}
+
+	names := map[string]int{}
+	for _, job := range jobs {
+		names[jobOutputName(job)]++
+	}
+
+	outputs := map[string]string{}
+	for i, job := range jobs {
+		// Episodes with the same file name (like in different seasons) are
+		// named after their identifier instead
+		name := jobOutputName(job)
+		if names[name] > 1 {
+			name = job.episode + ".mkv"
+		}
+		jobs[i].output = filepath.Join(output, name)
+
+		if episode, ok := outputs[name]; ok {
+			return fmt.Errorf("%s and %s would be remuxed to the same output %s", episode, job.episode, jobs[i].output)
+		}
+		outputs[name] = job.episode
+
+		for _, input := range job.inputs {
+			if models.SameFile(input, jobs[i].output) {
+				return fmt.Errorf("%s output would overwrite one of its inputs", jobs[i].output)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expandInput returns all the media files in the given directory (recursively)
+// or matching the given glob pattern. Anything else is returned as is.
+func expandInput(arg string) (source inputSource, err error) {
+	if isDir(arg) {
+		source.expanded = true
+		err = filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && mediaExtensions[strings.ToLower(filepath.Ext(path))] {
+				source.files = append(source.files, path)
+			}
+
+			return nil
+		})
+
+		return
+	}
+
+	if _, err := os.Stat(arg); err != nil && strings.ContainsAny(arg, "*?[") {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return source, fmt.Errorf("%s: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			return source, fmt.Errorf("%s: no files match the pattern", arg)
+		}
+
+		source.expanded = true
+		for _, match := range matches {
+			if !isDir(match) && mediaExtensions[strings.ToLower(filepath.Ext(match))] {
+				source.files = append(source.files, match)
+			}
+		}
+
+		return source, nil
+	}
+
+	source.files = []string{arg}
+
+	return
+}
+
+// episodeID extracts the season and episode numbers from a file path, using
+// the parent directories to find the season when the file name has none.
+func episodeID(path string) (string, bool) {
+	name := filepath.Base(path)
+
+	if match := seasonEpisodeRegexp.FindStringSubmatch(name); match != nil {
+		return formatEpisode(match[1], match[2]), true
+	}
+
+	if match := crossEpisodeRegexp.FindStringSubmatch(name); match != nil {
+		return formatEpisode(match[1], match[2]), true
+	}
+
+	if match := episodeRegexp.FindStringSubmatch(name); match != nil {
+		season := "1"
+		if dir := seasonRegexp.FindStringSubmatch(filepath.Base(filepath.Dir(path))); dir != nil {
+			season = dir[1]
+		}
+
+		return formatEpisode(season, match[1]), true
+	}
+
+	return "", false
+}
+
+// jobOutputName returns the output file name of a job, taken from its first
+// input with the episode identifier (shared inputs have none)
+func jobOutputName(job remuxJob) string {
+	for _, input := range job.inputs {
+		if episode, ok := episodeID(input); ok && episode == job.episode {
+			name := filepath.Base(input)
+			return strings.TrimSuffix(name, filepath.Ext(name)) + ".mkv"
+		}
+	}
+
+	return job.episode + ".mkv"
+}
+
+func formatEpisode(season, episode string) string {
+	s, _ := strconv.Atoi(season)
+	e, _ := strconv.Atoi(episode)
+
+	return fmt.Sprintf("S%02dE%02d", s, e)
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestEpisodeIDParsesCommonFormats(t *testing.T) {
+	cases := map[string]string{
+		"Show.S01E02.1080p.mkv":          "S01E02",
+		"show s1e2.mkv":                  "S01E02",
+		"Show - S02.E10 - Title.mkv":     "S02E10",
+		"Show 1x02.mkv":                  "S01E02",
+		"Show - ep 02.mkv":               "S01E02",
+		"Season 3/Show Episode 4.mka":    "S03E04",
+		"Temporada 2/E05.srt":            "S02E05",
+		"Show.2019.1080p.x264.mkv":       "",
+		"Movie (1920x1080).mkv":          "",
+		"Some.Series.S01E100.mkv":        "S01E100",
+		filepath.Join("S04", "ep12.mkv"): "S04E12",
+	}
+
+	for name, expected := range cases {
+		id, ok := episodeID(name)
+		tests.Assert(t, ok == (expected != ""), "unexpected match result for %s", name)
+		tests.Equals(t, expected, id)
+	}
+}
+
+func touch(t *testing.T, paths ...string) {
+	for _, path := range paths {
+		tests.Ok(t, os.MkdirAll(filepath.Dir(path), 0755))
+		tests.Ok(t, ioutil.WriteFile(path, nil, 0644))
+	}
+}
+
+func TestBuildJobsKeepsPlainInputsAsIs(t *testing.T) {
+	jobs, err := buildJobs("out.mkv", []string{"a.mkv", "b.mkv"})
+
+	tests.Ok(t, err)
+	tests.Equals(t, []remuxJob{{output: "out.mkv", inputs: []string{"a.mkv", "b.mkv"}, arguments: []int{1, 2}}}, jobs)
+}
+
+func TestBuildJobsGroupsEpisodesFromDirectoriesAndGlobs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remuxing")
+	tests.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	eng := filepath.Join(dir, "eng")
+	spa := filepath.Join(dir, "spa")
+	touch(t,
+		filepath.Join(eng, "Show.S01E01.mkv"),
+		filepath.Join(eng, "Show.S01E02.mkv"),
+		filepath.Join(eng, "notes.txt"),
+		filepath.Join(spa, "Serie 1x01.mka"),
+		filepath.Join(spa, "Serie 1x02.mka"),
+		filepath.Join(spa, "extra", "Serie 1x02.srt"),
+		filepath.Join(dir, "chapters.mks"),
+	)
+
+	out := filepath.Join(dir, "out")
+	jobs, err := buildJobs(out, []string{
+		eng,
+		filepath.Join(dir, "chapters.mks"),
+		filepath.Join(spa, "*"),
+		filepath.Join(spa, "extra"),
+	})
+
+	tests.Ok(t, err)
+	tests.Equals(t, []remuxJob{
+		{
+			episode: "S01E01",
+			output:  filepath.Join(out, "Show.S01E01.mkv"),
+			inputs: []string{
+				filepath.Join(eng, "Show.S01E01.mkv"),
+				filepath.Join(dir, "chapters.mks"),
+				filepath.Join(spa, "Serie 1x01.mka"),
+			},
+			arguments: []int{1, 2, 3},
+		},
+		{
+			episode: "S01E02",
+			output:  filepath.Join(out, "Show.S01E02.mkv"),
+			inputs: []string{
+				filepath.Join(eng, "Show.S01E02.mkv"),
+				filepath.Join(dir, "chapters.mks"),
+				filepath.Join(spa, "Serie 1x02.mka"),
+				filepath.Join(spa, "extra", "Serie 1x02.srt"),
+			},
+			arguments: []int{1, 2, 3, 4},
+		},
+	}, jobs)
+	tests.Assert(t, isDir(out), "output directory should have been created")
+}
+
+func TestBuildJobsAvoidsOutputCollisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remuxing")
+	tests.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	touch(t,
+		filepath.Join(dir, "chapters.mks"),
+		filepath.Join(dir, "Season 1", "Show ep 01.mkv"),
+		filepath.Join(dir, "Season 1", "Show ep 02.mkv"),
+		filepath.Join(dir, "Season 2", "Show ep 01.mkv"),
+	)
+
+	out := filepath.Join(dir, "out")
+	jobs, err := buildJobs(out, []string{filepath.Join(dir, "chapters.mks"), dir})
+
+	tests.Ok(t, err)
+	var outputs []string
+	for _, job := range jobs {
+		outputs = append(outputs, job.output)
+	}
+	// Named after their episode file, not after the shared input
+	tests.Equals(t, []string{
+		filepath.Join(out, "S01E01.mkv"),
+		filepath.Join(out, "Show ep 02.mkv"),
+		filepath.Join(out, "S02E01.mkv"),
+	}, outputs)
+}
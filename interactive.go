@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elboletaire/remuxing/models"
+)
+
+// errAborted is returned when the remux is cancelled from the interactive mode
+var errAborted = errors.New("remux aborted")
+
+// picker holds the tracks shown by the interactive mode, grouped by type, with
+// the automatic selection pre-checked
+type picker struct {
+	groups []pickerGroup
+}
+
+type pickerGroup struct {
+	name    string
+	title   string
+	entries []pickerEntry
+}
+
+type pickerEntry struct {
+	track    models.TrackController
+	selected bool
+}
+
+// newPicker lists all the tracks of the inputs, the selected ones first (in
+// their selection order) and then the rest of them in the inputs order
+func newPicker(
+	tracks *models.TracksController,
+	video *models.TrackController,
+	audios models.Tracks,
+	subtitles models.Tracks,
+) *picker {
+	var videos models.Tracks
+	if video != nil {
+		videos = models.Tracks{*video}
+	}
+
+	selections := map[string]models.Tracks{"video": videos, "audio": audios, "subtitles": subtitles}
+	p := &picker{groups: []pickerGroup{
+		{name: "video", title: "VIDEOS"},
+		{name: "audio", title: "AUDIOS"},
+		{name: "subtitles", title: "SUBTITLES"},
+	}}
+
+	for i := range p.groups {
+		group := &p.groups[i]
+		for _, track := range selections[group.name] {
+			group.entries = append(group.entries, pickerEntry{track: track, selected: true})
+		}
+	}
+
+	for _, input := range tracks.Inputs {
+		for _, track := range input.Tracks {
+			track.Input = input
+			group := p.group(track.Track.Type)
+			if group != nil && !group.contains(track) {
+				group.entries = append(group.entries, pickerEntry{track: track})
+			}
+		}
+	}
+
+	return p
+}
+
+func (p *picker) group(name string) *pickerGroup {
+	for i := range p.groups {
+		if p.groups[i].name == name {
+			return &p.groups[i]
+		}
+	}
+
+	return nil
+}
+
+func (group *pickerGroup) contains(track models.TrackController) bool {
+	for _, entry := range group.entries {
+		if entry.track.Track == track.Track {
+			return true
+		}
+	}
+
+	return false
+}
+
+// entry returns the group and the index of the track with the given number, as
+// shown by printPicker (starting at 1 and going on through all the groups)
+func (p *picker) entry(value string) (*pickerGroup, int, error) {
+	number, err := strconv.Atoi(value)
+	if err == nil {
+		for i := range p.groups {
+			group := &p.groups[i]
+			if number > 0 && number <= len(group.entries) {
+				return group, number - 1, nil
+			}
+			number -= len(group.entries)
+		}
+	}
+
+	return nil, 0, fmt.Errorf("unknown track %q", value)
+}
+
+// selection returns the selected tracks, as expected by CommandArguments
+func (p *picker) selection() (video *models.TrackController, audios, subtitles models.Tracks) {
+	for _, entry := range p.group("video").entries {
+		if entry.selected {
+			track := entry.track
+			video = &track
+		}
+	}
+
+	for _, entry := range p.group("audio").entries {
+		if entry.selected {
+			audios = append(audios, entry.track)
+		}
+	}
+
+	for _, entry := range p.group("subtitles").entries {
+		if entry.selected {
+			subtitles = append(subtitles, entry.track)
+		}
+	}
+
+	return
+}
+
+// isDefault tells whether the track will be flagged as default, which is the
+// first selected audio and, for the other tracks, their current flag unless
+// told otherwise
+func (p *picker) isDefault(group *pickerGroup, index int) bool {
+	track := group.entries[index].track.Track
+	if track.OutputDefault != nil {
+		return *track.OutputDefault
+	}
+
+	if group.name != "audio" {
+		return track.Properties.Default
+	}
+
+	for i, entry := range group.entries {
+		if entry.selected {
+			return i == index
+		}
+	}
+
+	return false
+}
+
+// run runs one of the interactive mode commands (see pickerHelp), returning
+// true once the tracks are chosen
+func (p *picker) run(line string) (done bool, err error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	command, args := fields[0], fields[1:]
+	switch command {
+	case "go", "g":
+		if video, _, _ := p.selection(); video == nil {
+			return false, errors.New("a video track must be selected")
+		}
+		return true, nil
+	case "quit", "q":
+		return false, errAborted
+	case "toggle", "t":
+		if len(args) == 0 {
+			return false, errors.New("expected the tracks to toggle, like: t 3 5")
+		}
+		for _, arg := range args {
+			if err = p.toggle(arg); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	if len(args) == 0 {
+		return false, fmt.Errorf("unknown command %q, type h for help", line)
+	}
+
+	group, index, err := p.entry(args[0])
+	if err != nil {
+		return
+	}
+	track := group.entries[index].track.Track
+
+	switch command {
+	case "move", "m":
+		return false, p.move(group, index, args[1:])
+	case "default", "d":
+		isDefault := !p.isDefault(group, index)
+		// Just one default audio
+		if group.name == "audio" && isDefault {
+			for _, entry := range group.entries {
+				no := false
+				entry.track.Track.OutputDefault = &no
+			}
+		}
+		track.OutputDefault = &isDefault
+	case "forced", "f":
+		if group.name != "subtitles" {
+			return false, errors.New("only subtitles can be flagged as forced")
+		}
+		isForced := !track.Properties.Forced
+		track.Properties.Forced = isForced
+		track.OutputForced = &isForced
+		track.ForcedDetected = false
+	case "lang", "l":
+		if len(args) != 2 {
+			return false, errors.New("expected a track and a language, like: l 3 spa")
+		}
+		track.SetLanguage(args[1])
+	case "name", "n":
+		// Everything after the track number, spaces included
+		rest := line[strings.Index(line, command)+len(command):]
+		track.OutputName = strings.TrimSpace(rest[strings.Index(rest, args[0])+len(args[0]):])
+	default:
+		return false, fmt.Errorf("unknown command %q, type h for help", command)
+	}
+
+	return
+}
+
+// toggle selects or unselects a track. Just one video can be selected, so
+// toggling a video selects it instead.
+func (p *picker) toggle(value string) error {
+	group, index, err := p.entry(value)
+	if err != nil {
+		return err
+	}
+
+	if group.name == "video" {
+		for i := range group.entries {
+			group.entries[i].selected = i == index
+		}
+		return nil
+	}
+
+	group.entries[index].selected = !group.entries[index].selected
+
+	return nil
+}
+
+// move moves a track to the given position (starting at 1) of its group, as
+// the order of the output tracks follows the order of the groups
+func (p *picker) move(group *pickerGroup, index int, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a track and its new position, like: m 5 1")
+	}
+
+	position, err := strconv.Atoi(args[0])
+	if err != nil || position < 1 || position > len(group.entries) {
+		return fmt.Errorf("invalid position %q, expected 1 to %d", args[0], len(group.entries))
+	}
+
+	entry := group.entries[index]
+	entries := append(group.entries[:index:index], group.entries[index+1:]...)
+	entries = append(entries[:position-1], append([]pickerEntry{entry}, entries[position-1:]...)...)
+	group.entries = entries
+
+	return nil
+}
+
+const pickerHelp = `Commands (tracks are given by their number):
+  t 3 5       toggle tracks (selects the video, as just one can be taken)
+  m 5 1       move track 5 to the first position of its group
+  d 3         toggle the default flag
+  f 6         toggle the forced flag (subtitles only)
+  l 3 spa     set the language
+  n 3 name    set the track name (removed when empty)
+  g           go, remux the selected tracks
+  q           quit without remuxing
+`
+
+// pickTracks lets the user tweak the automatic selection, reading commands
+// until they're done. The same scanner is used for all the remuxes, so
+// commands given ahead are not lost.
+func pickTracks(
+	scanner *bufio.Scanner,
+	tracks *models.TracksController,
+	video *models.TrackController,
+	audios models.Tracks,
+	subtitles models.Tracks,
+) (*models.TrackController, models.Tracks, models.Tracks, error) {
+	p := newPicker(tracks, video, audios, subtitles)
+
+	printPicker(p)
+	for {
+		prompt()
+		if !scanner.Scan() {
+			return nil, nil, nil, errAborted
+		}
+
+		line := scanner.Text()
+		if command := strings.TrimSpace(line); command == "h" || command == "help" || command == "?" {
+			fmt.Print(pickerHelp)
+			continue
+		}
+
+		done, err := p.run(line)
+		if err == errAborted {
+			return nil, nil, nil, err
+		}
+		if err != nil {
+			warning(err.Error())
+			continue
+		}
+		if done {
+			video, audios, subtitles := p.selection()
+			return video, audios, subtitles, nil
+		}
+
+		printPicker(p)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/elboletaire/remuxing/models"
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func pickerTracks() (tracks models.TracksController) {
+	for position, file := range []string{"a.mkv", "b.mkv"} {
+		input := &models.Info{FileName: file, Position: position}
+		for id, kind := range []string{"video", "audio", "subtitles"} {
+			track := models.TrackController{Track: &models.Track{ID: uint(id), Type: kind}}
+			track.Track.SetLanguage("eng")
+			input.Tracks = append(input.Tracks, track)
+		}
+		tracks.Inputs = append(tracks.Inputs, input)
+	}
+
+	return
+}
+
+// selected returns the given input tracks, as selected by the tracks controller
+func selected(tracks models.TracksController, input, id int) models.TrackController {
+	track := tracks.Inputs[input].Tracks[id]
+	track.Input = tracks.Inputs[input]
+
+	return track
+}
+
+func TestPickerStartsFromTheAutomaticSelection(t *testing.T) {
+	tracks := pickerTracks()
+	video := selected(tracks, 1, 0)
+	audio := selected(tracks, 1, 1)
+
+	p := newPicker(&tracks, &video, models.Tracks{audio}, nil)
+
+	// Selected tracks go first
+	tests.Equals(t, "b.mkv", p.groups[0].entries[0].track.Input.FileName)
+	tests.Equals(t, "a.mkv", p.groups[0].entries[1].track.Input.FileName)
+
+	pickedVideo, audios, subtitles := p.selection()
+	tests.Equals(t, video.Track, pickedVideo.Track)
+	tests.Equals(t, models.Tracks{audio}, audios)
+	tests.Equals(t, 0, len(subtitles))
+	tests.Assert(t, p.isDefault(&p.groups[1], 0), "the first audio should be the default one")
+}
+
+func TestPickerCommands(t *testing.T) {
+	tracks := pickerTracks()
+	video := selected(tracks, 0, 0)
+	audio := selected(tracks, 0, 1)
+
+	p := newPicker(&tracks, &video, models.Tracks{audio}, nil)
+
+	// 1-2 videos, 3-4 audios and 5-6 subtitles
+	for _, command := range []string{"t 2", "t 4 5", "m 4 1", "d 4", "l 3 es-419", "n 5  Forced  subs ", "f 5"} {
+		done, err := p.run(command)
+		tests.Ok(t, err)
+		tests.Assert(t, !done, "%q should not finish", command)
+	}
+
+	pickedVideo, audios, subtitles := p.selection()
+	tests.Equals(t, "b.mkv", pickedVideo.Input.FileName)
+	tests.Equals(t, 2, len(audios))
+	tests.Equals(t, "b.mkv", audios[0].Input.FileName)
+	tests.Equals(t, "es-419", audios[0].Track.GetLanguage())
+	tests.Equals(t, false, *audios[0].Track.OutputDefault)
+	tests.Equals(t, true, *audios[1].Track.OutputDefault)
+	tests.Equals(t, 1, len(subtitles))
+	tests.Equals(t, "Forced  subs", subtitles[0].Track.OutputName)
+	tests.Equals(t, true, subtitles[0].Track.Properties.Forced)
+
+	for _, command := range []string{"t 7", "f 3", "m 3 3", "l 3", "x 3", "t"} {
+		_, err := p.run(command)
+		tests.Assert(t, err != nil, "%q should fail", command)
+	}
+
+	_, err := p.run("q")
+	tests.Equals(t, errAborted, err)
+
+	done, err := p.run("g")
+	tests.Ok(t, err)
+	tests.Assert(t, done, "go should finish")
+}
+
+func TestPickerFlagsAreSetToTheOutput(t *testing.T) {
+	tracks := pickerTracks()
+	tracks.Inputs[0].Tracks[2].Track.Properties.Forced = true
+	video := selected(tracks, 0, 0)
+	subtitle := selected(tracks, 0, 2)
+
+	p := newPicker(&tracks, &video, nil, models.Tracks{subtitle})
+
+	// 1-2 videos, 3-4 audios and 5-6 subtitles
+	for _, command := range []string{"d 1", "f 5"} {
+		_, err := p.run(command)
+		tests.Ok(t, err)
+	}
+
+	pickedVideo, audios, subtitles := p.selection()
+	command, err := CommandArguments("out.mkv", pickedVideo, audios, subtitles)
+	tests.Ok(t, err)
+
+	arguments := strings.Join(command, " ")
+	tests.Assert(t, strings.Contains(arguments, "-d 0 --default-track 0:true a.mkv"), "video default flag missing in %s", arguments)
+	tests.Assert(t, strings.Contains(arguments, "--forced-track 2:false"), "cleared forced flag missing in %s", arguments)
+}
+
+func TestPickTracksKeepsReadingFromTheSameScanner(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("t 4\ng\nt 6\ng\n"))
+
+	for _, expected := range []string{"audio", "subtitles"} {
+		tracks := pickerTracks()
+		video := selected(tracks, 0, 0)
+
+		_, audios, subtitles, err := pickTracks(scanner, &tracks, &video, nil, nil)
+		tests.Ok(t, err)
+		tests.Equals(t, 1, len(audios)+len(subtitles))
+		tests.Equals(t, expected, append(audios, subtitles...)[0].Track.Type)
+	}
+}
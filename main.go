@@ -1,30 +1,117 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/elboletaire/remuxing/models"
 )
 
 const gray = 13
 
-func parseArgs() (
-	output string,
-	inputs []string,
-	languages []string,
-	verbose bool,
-) {
-	flag.StringVar(&output, "output", "", "The output file.")
+type options struct {
+	remuxes     []remuxJob
+	languages   []string
+	overrides   languageOverrides
+	preferences models.Preferences
+	videoFilter trackFilter
+	audioFilter trackFilter
+	subsFilter  trackFilter
+	prober      models.Prober
+	cacheDir    string
+	clearCache  bool
+	jobs        int
+	skipLength  bool
+	tolerance   time.Duration
+	inference   bool
+	forced      bool
+	detectSync  bool
+	syncOffset  time.Duration
+	frameRates  bool
+	explain     bool
+	interactive bool
+	commands    *bufio.Scanner
+	report      *explainReport
+	verbose     bool
+}
+
+func parseArgs() (opts options) {
+	var output string
+	flag.StringVar(&output, "output", "", "The output file (or directory, when remuxing multiple episodes).")
 
 	var lang string
 	flag.StringVar(&lang, "languages", "", "Languages to be taken from inputs. Order matters, first one will be marked as default track.")
 
+	flag.Var(&opts.overrides, "set-language", "Sets the language of a track before selecting them, as input:track=language (like input2.mkv:1=spa or 2:1=spa). Repeatable.")
+
+	flag.Var(&opts.videoFilter, "video", "Only take the video tracks matching the given filter expression, like 'height >= 1080'.")
+	flag.Var(&opts.audioFilter, "audio", "Only take the audio tracks matching the given filter expression, like 'lang in (spa, eng) and channels >= 6'.")
+	flag.Var(&opts.subsFilter, "subs", "Only take the subtitle tracks matching the given filter expression, like 'lang == eng and not forced'.")
+
+	var videoWeights string
+	flag.StringVar(&videoWeights, "video-weights", "", "Weights of the video properties used to choose the video track, like resolution=4,codec=2,bitdepth=1,hdr=1,bitrate=1,position=0.01.")
+
+	var audioPreset, audioCodecs string
+	flag.StringVar(&audioPreset, "audio-preset", "default", "Audio codecs ranking used to choose between the audios of the same language: default, archival or compatible.")
+	flag.StringVar(&audioCodecs, "audio-codecs", "", "Custom audio codecs ranking, like truehd-atmos,truehd,flac,aac (overrides -audio-preset).")
+
+	flag.BoolVar(&opts.preferences.Dedupe, "dedupe", false, "Without -languages, only take the best audio and subtitle tracks of each language and kind.")
+
+	var subtitleFormats string
+	flag.StringVar(&subtitleFormats, "subtitle-formats", "", "Subtitle formats ranking used to choose between the subtitles of the same language, like srt,ass,pgs (defaults to ass,srt,webvtt,textst,pgs,vobsub,dvb).")
+
+	var noForced bool
+	flag.BoolVar(&noForced, "no-forced-detection", false, "Do not flag as forced the subtitles with way less events than the other ones of their language.")
+
+	var audioSet string
+	flag.StringVar(&audioSet, "audio-set", "main", "Audio tracks taken for each language: main, surround, stereo, commentary and/or description, like main,stereo,commentary.")
+
+	include := map[string]*bool{}
+	include[models.KindCommentary] = flag.Bool("include-commentary", false, "Also take the commentary tracks of each language (excluded by default).")
+	include[models.KindDescription] = flag.Bool("include-description", false, "Also take the audio description tracks of each language (excluded by default).")
+	include[models.KindSDH] = flag.Bool("include-sdh", false, "Also take the SDH subtitles of each language (excluded by default).")
+	include[models.KindSigns] = flag.Bool("include-signs", false, "Also take the signs & songs subtitles of each language (excluded by default).")
+
+	var proberName, fixtures string
+	flag.StringVar(&proberName, "prober", "mkvmerge", "Tool used to identify the inputs: mkvmerge, ffprobe, native or fixture.")
+	flag.StringVar(&fixtures, "fixtures", "", "Directory with the mkvmerge JSON identification fixtures used by -prober fixture.")
+
+	var noCache bool
+	flag.BoolVar(&noCache, "no-cache", false, "Always identify the inputs, instead of using the cached results.")
+	flag.BoolVar(&opts.clearCache, "clear-cache", false, "Remove all the cached identification results before running.")
+
+	flag.IntVar(&opts.jobs, "jobs", runtime.NumCPU(), "Number of inputs identified concurrently.")
+
+	flag.BoolVar(&opts.skipLength, "S", false, "Skip checking that all inputs have the same duration.")
+	flag.DurationVar(&opts.tolerance, "duration-tolerance", 2*time.Second, "Maximum duration difference allowed between the video and the other inputs.")
+
+	var noSidecars bool
+	flag.BoolVar(&noSidecars, "no-sidecars", false, "Do not look for subtitle and audio files named after the video inputs.")
+
+	flag.BoolVar(&opts.detectSync, "detect-sync", false, "Detect the offset of the audios taken from other inputs than the video one (requires ffmpeg).")
+	flag.DurationVar(&opts.syncOffset, "sync-max-offset", 30*time.Second, "Maximum offset looked for by -detect-sync.")
+
+	var noStretch bool
+	flag.BoolVar(&noStretch, "no-stretch", false, "Do not stretch the tracks of inputs with a different frame rate than the video.")
+
+	var noInference bool
+	flag.BoolVar(&noInference, "no-inference", false, "Do not guess the language of the tracks tagged as undefined.")
+
+	var explainJSON string
+	flag.BoolVar(&opts.explain, "explain", false, "Print why every track of the inputs was selected or rejected.")
+	flag.StringVar(&explainJSON, "explain-json", "", "Write why every track of the inputs was selected or rejected as JSON to the given file (or - for the standard output).")
+
+	flag.BoolVar(&opts.interactive, "i", false, "Interactive mode, to tweak the selected tracks before remuxing them.")
+	flag.BoolVar(&opts.interactive, "interactive", false, "Same as -i.")
+
 	var help bool
 	flag.BoolVar(&help, "h", false, "This help.")
-	flag.BoolVar(&verbose, "v", false, "Verbose.")
+	flag.BoolVar(&opts.verbose, "v", false, "Verbose.")
 	flag.Parse()
 
 	if help || len(os.Args) == 1 {
@@ -41,46 +128,267 @@ func parseArgs() (
 		syntaxError("-output path missing")
 	}
 
-	inputs = flag.Args()
+	remuxes, err := buildJobs(output, flag.Args())
+	if err != nil {
+		syntaxError(err.Error())
+	}
+
+	for _, job := range remuxes {
+		if !noSidecars {
+			addSidecars(&job)
+		}
+
+		if len(job.inputs) < 2 {
+			if len(remuxes) == 1 {
+				syntaxError("at least two inputs are expected")
+			}
+			warning(fmt.Sprintf("skipping %s, as it was only found in %s", job.episode, job.inputs[0]))
+			continue
+		}
+		opts.remuxes = append(opts.remuxes, job)
+	}
+
+	if len(opts.remuxes) == 0 {
+		syntaxError("no episode was found in at least two inputs")
+	}
+
+	if err := checkLanguageOverrides(opts.overrides, opts.remuxes); err != nil {
+		syntaxError(err.Error())
+	}
+
+	opts.inference = !noInference
+	opts.forced = !noForced
+	opts.frameRates = !noStretch
+
+	if opts.interactive {
+		opts.commands = bufio.NewScanner(os.Stdin)
+	}
+
+	if len(explainJSON) > 0 {
+		opts.report = &explainReport{path: explainJSON}
+	}
+
+	if len(videoWeights) > 0 {
+		weights, err := models.ParseVideoWeights(videoWeights)
+		if err != nil {
+			syntaxError(err.Error())
+		}
+		opts.preferences.VideoWeights = &weights
+	}
+
+	switch {
+	case len(audioCodecs) > 0:
+		codecs, err := models.ParseAudioCodecs(audioCodecs)
+		if err != nil {
+			syntaxError(err.Error())
+		}
+		opts.preferences.AudioCodecs = codecs
+	case models.AudioPresets[audioPreset] != nil:
+		opts.preferences.AudioCodecs = models.AudioPresets[audioPreset]
+	default:
+		syntaxError(fmt.Sprintf("unknown audio preset %q", audioPreset))
+	}
+
+	if opts.preferences.AudioSet, err = models.ParseAudioSet(audioSet); err != nil {
+		syntaxError(err.Error())
+	}
 
-	if len(inputs) < 2 {
-		syntaxError("at least two inputs are expected")
+	if len(subtitleFormats) > 0 {
+		if opts.preferences.SubtitleFormats, err = models.ParseSubtitleFormats(subtitleFormats); err != nil {
+			syntaxError(err.Error())
+		}
+	}
+
+	for _, kind := range models.OptionalKinds {
+		if *include[kind] {
+			opts.preferences.IncludeKinds = append(opts.preferences.IncludeKinds, kind)
+		}
 	}
 
 	if len(lang) > 0 {
-		languages = strings.Split(lang, ",")
+		opts.languages = strings.Split(lang, ",")
+	}
+
+	switch proberName {
+	case "mkvmerge":
+		opts.prober = models.MkvmergeProber{}
+	case "ffprobe":
+		opts.prober = models.FFprobeProber{}
+	case "native":
+		opts.prober = models.NativeProber{}
+	case "fixture":
+		if len(fixtures) == 0 {
+			syntaxError("-fixtures directory missing")
+		}
+		opts.prober = models.FixtureProber{Dir: fixtures}
+	default:
+		syntaxError(fmt.Sprintf("unknown prober %q", proberName))
+	}
+
+	opts.cacheDir, _ = models.DefaultCacheDir()
+
+	// Fixtures are already a kind of cache
+	if noCache || proberName == "fixture" || len(opts.cacheDir) == 0 {
+		return
+	}
+
+	opts.prober = models.CachedProber{
+		Prober:  opts.prober,
+		Dir:     opts.cacheDir,
+		Version: models.ProberVersion(proberName),
 	}
 
 	return
 }
 
 func main() {
-	output, inputs, languages, verbose := parseArgs()
+	opts := parseArgs()
+
+	if opts.clearCache && len(opts.cacheDir) > 0 {
+		if err := (models.CachedProber{Dir: opts.cacheDir}).Clear(); err != nil {
+			fatal(err)
+		}
+	}
+
+	if len(opts.remuxes) == 1 {
+		err := remux(opts, opts.remuxes[0])
+		writeReport(opts)
+		if err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	// Keep going with the other episodes when one fails, exiting with the
+	// code of the last failure
+	var failure error
+	for _, job := range opts.remuxes {
+		title(fmt.Sprintf("%s: %s", job.episode, job.output))
+		if err := remux(opts, job); err != nil {
+			printError(err)
+			failure = err
+		}
+	}
+
+	writeReport(opts)
+
+	if failure != nil {
+		os.Exit(exitCode(failure))
+	}
+}
+
+// writeReport writes the -explain-json report, if asked to
+func writeReport(opts options) {
+	if opts.report == nil {
+		return
+	}
+
+	if err := opts.report.write(); err != nil {
+		fatal(err)
+	}
+}
+
+func remux(opts options, job remuxJob) error {
+	tracks, err := models.BuildTracks(opts.prober, job.inputs, opts.jobs)
+	if err != nil {
+		return err
+	}
+
+	tracks.Preferences = opts.preferences
+
+	applySidecarHints(tracks, job.sidecars)
+
+	if err = tracks.ApplyLanguageOverrides(jobLanguageOverrides(opts.overrides, job)); err != nil {
+		return err
+	}
+
+	if opts.inference {
+		tracks.InferLanguages()
+	}
 
-	tracks := models.BuildTracks(inputs)
+	if opts.forced {
+		tracks.DetectForcedSubtitles()
+	}
+
+	tracks.Videos = opts.videoFilter.apply(tracks.Videos)
+	tracks.Audios = opts.audioFilter.apply(tracks.Audios)
+	tracks.Subtitles = opts.subsFilter.apply(tracks.Subtitles)
+
+	video, err := tracks.GetBestVideo()
+	if err != nil {
+		return err
+	}
+
+	audios, err := tracks.GetBestAudios(opts.languages)
+	subtitles := tracks.GetBestSubtitles(opts.languages)
+
+	// Explained before failing, as it tells why a language was not found
+	if opts.explain || opts.report != nil {
+		decisions := tracks.Explain(opts.languages, video, audios, subtitles)
+		if opts.explain {
+			printDecisions(decisions)
+		}
+		if opts.report != nil {
+			opts.report.add(job.output, decisions)
+		}
+	}
+
+	// Missing languages can be fixed by hand in the interactive mode
+	if err != nil && !opts.interactive {
+		return err
+	}
+
+	if opts.interactive {
+		if err != nil {
+			warning(err.Error())
+		}
 
-	video := tracks.GetBestVideo()
-	audios := tracks.GetBestAudios(languages)
-	subtitles := tracks.GetBestSubtitles(languages)
+		video, audios, subtitles, err = pickTracks(opts.commands, &tracks, video, audios, subtitles)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.frameRates {
+		models.DetectFrameRates(video, audios, subtitles)
+	}
+
+	if opts.detectSync {
+		detector := models.SyncDetector{Decoder: models.FFmpegDecoder{}, MaxOffset: opts.syncOffset}
+		// Tracks that could not be synced are muxed as they are
+		if err = detector.DetectSync(video, audios, subtitles); err != nil {
+			warning(err.Error())
+		}
+	}
 
-	command := CommandArguments(output, video, audios, subtitles)
+	if !opts.skipLength {
+		if err = models.CheckDurations(video, opts.tolerance, audios, subtitles); err != nil {
+			return err
+		}
+	}
 
-	if verbose {
-		title("VIDEOS")
-		printTrack(video)
+	command, err := CommandArguments(job.output, video, audios, subtitles)
+	if err != nil {
+		return err
+	}
+
+	if opts.verbose {
+		printInputs(tracks.Inputs)
+		printVideos(tracks.ScoreVideos())
 		printTracks("AUDIOS", audios)
 		printTracks("SUBTITLES", subtitles)
 		printCommand(command)
 	}
 
 	result, err := Command(command)
-
 	if err != nil {
-		panic(fmt.Sprint(err) + ": " + string(result))
+		return err
 	}
 
-	if verbose {
+	if opts.verbose {
 		title("OUTPUT")
 		fmt.Println(string(result))
 	}
+
+	return nil
 }
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+AudioPresets are the built-in audio codec rankings, from the most to the least
+preferred codec
+*/
+var AudioPresets = map[string][]string{
+	"default": {"aac", "vorbis", "opus", "ac3"},
+	"archival": {
+		"truehd-atmos", "truehd", "dts-hd-ma", "flac", "pcm", "alac",
+		"dts-hd-hra", "eac3", "dts", "ac3", "opus", "aac", "vorbis", "mp3", "mp2",
+	},
+	"compatible": {"aac", "ac3", "eac3", "mp3", "mp2", "dts", "opus", "vorbis"},
+}
+
+// Audio codecs by their Matroska codec IDs. Variants sharing their codec ID
+// (like TrueHD Atmos) are told apart by their codec name.
+var audioCodecs = map[string]string{
+	"A_AAC":            "aac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_DTS":            "dts",
+	"A_TRUEHD":         "truehd",
+	"A_FLAC":           "flac",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_MPEG/L3":        "mp3",
+	"A_MPEG/L2":        "mp2",
+	"A_ALAC":           "alac",
+	"A_PCM/INT/LIT":    "pcm",
+	"A_PCM/INT/BIG":    "pcm",
+	"A_PCM/FLOAT/IEEE": "pcm",
+}
+
+// Variants are found in the codec names given by mkvmerge and the ffprobe
+// prober, or in the track names (the only hint left with the native prober)
+var audioCodecVariants = []struct {
+	codec   string
+	names   *regexp.Regexp
+	variant string
+}{
+	{"truehd", regexp.MustCompile(`(?i)\batmos\b`), "truehd-atmos"},
+	{"dts", regexp.MustCompile(`(?i)master audio|\bdts-hd ?ma\b`), "dts-hd-ma"},
+	{"dts", regexp.MustCompile(`(?i)high resolution|\bdts-hd ?hra\b`), "dts-hd-hra"},
+}
+
+/*
+GetAudioCodec returns the codec of an audio track, as used by the audio codec
+rankings (like aac, truehd-atmos or dts-hd-ma), or an empty string if unknown
+*/
+func (track *Track) GetAudioCodec() string {
+	codec := audioCodecs[track.Properties.CodecID]
+	// AAC codec IDs used to include the profile, like A_AAC/MPEG4/LC
+	if codec == "" && strings.HasPrefix(track.Properties.CodecID, "A_AAC") {
+		codec = "aac"
+	}
+
+	for _, variant := range audioCodecVariants {
+		if codec != variant.codec {
+			continue
+		}
+		if variant.names.MatchString(track.Codec) || variant.names.MatchString(track.Properties.TrackName) {
+			return variant.variant
+		}
+	}
+
+	return codec
+}
+
+/*
+ParseAudioCodecs parses a comma separated audio codecs ranking, like
+"truehd,flac,aac", failing with unknown codecs
+*/
+func ParseAudioCodecs(value string) (codecs []string, err error) {
+	known := map[string]bool{}
+	for _, ranking := range AudioPresets {
+		for _, codec := range ranking {
+			known[codec] = true
+		}
+	}
+
+	for _, codec := range strings.Split(value, ",") {
+		codec = strings.ToLower(strings.TrimSpace(codec))
+		if !known[codec] {
+			return nil, fmt.Errorf("unknown audio codec %q", codec)
+		}
+		codecs = append(codecs, codec)
+	}
+
+	return
+}
+
+// sortAudios sorts the audios by the codecs ranking (tracks with codecs out of
+// it go last), then by their channels, bitrate and input position
+func (preferences Preferences) sortAudios(audios Tracks) {
+	sort.SliceStable(audios, func(i, j int) bool {
+		return preferences.audioAdvantage(audios[i], audios[j]) != ""
+	})
+}
+
+// audioAdvantage returns why the audio a is preferred over b, or an empty
+// string when it's not
+func (preferences Preferences) audioAdvantage(a, b TrackController) string {
+	rankA, rankB := preferences.audioRank(a), preferences.audioRank(b)
+	channelsA, channelsB := a.Track.Properties.AudioChannels, b.Track.Properties.AudioChannels
+	bitrateA, bitrateB := a.Track.GetBitrate(), b.Track.GetBitrate()
+
+	switch {
+	case rankA != rankB:
+		return advantage(rankA < rankB, "better codec rank")
+	case channelsA != channelsB:
+		return advantage(channelsA > channelsB, "more channels")
+	case bitrateA != bitrateB:
+		return advantage(bitrateA > bitrateB, "higher bitrate")
+	}
+
+	return advantage(a.position() > b.position(), "later input")
+}
+
+// audioRank returns the position of the audio codec in the ranking, with the
+// codecs out of it going last
+func (preferences Preferences) audioRank(track TrackController) int {
+	codecs := preferences.audioCodecs()
+	for i, codec := range codecs {
+		if codec == track.Track.GetAudioCodec() {
+			return i
+		}
+	}
+
+	return len(codecs)
+}
+
+func advantage(better bool, reason string) string {
+	if better {
+		return reason
+	}
+
+	return ""
+}
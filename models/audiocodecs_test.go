@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func rankedAudio(id uint, codecID, codec string, channels int, bps string) TrackController {
+	return TrackController{
+		Input: &Info{Position: 0},
+		Track: &Track{
+			ID:    id,
+			Type:  "audio",
+			Codec: codec,
+			Properties: properties{
+				CodecID:       codecID,
+				Language:      "eng",
+				AudioChannels: channels,
+				TagBps:        bps,
+			},
+		},
+	}
+}
+
+func TestGetAudioCodecTellsVariantsApart(t *testing.T) {
+	tests.Equals(t, "truehd-atmos", rankedAudio(0, "A_TRUEHD", "TrueHD Atmos", 8, "").Track.GetAudioCodec())
+	tests.Equals(t, "truehd", rankedAudio(0, "A_TRUEHD", "TrueHD", 8, "").Track.GetAudioCodec())
+	tests.Equals(t, "dts-hd-ma", rankedAudio(0, "A_DTS", "DTS-HD Master Audio", 6, "").Track.GetAudioCodec())
+	tests.Equals(t, "dts", rankedAudio(0, "A_DTS", "DTS", 6, "").Track.GetAudioCodec())
+	tests.Equals(t, "aac", rankedAudio(0, "A_AAC/MPEG4/LC", "AAC", 2, "").Track.GetAudioCodec())
+	tests.Equals(t, "pcm", rankedAudio(0, "A_PCM/INT/LIT", "PCM", 2, "").Track.GetAudioCodec())
+	tests.Equals(t, "", rankedAudio(0, "A_QUICKTIME", "QDesign", 2, "").Track.GetAudioCodec())
+
+	// Codec names given by the ffprobe prober
+	tests.Equals(t, "truehd-atmos", rankedAudio(0, "A_TRUEHD", ffprobeCodecName("A_TRUEHD", "Dolby TrueHD + Dolby Atmos"), 8, "").Track.GetAudioCodec())
+	tests.Equals(t, "dts-hd-ma", rankedAudio(0, "A_DTS", ffprobeCodecName("A_DTS", "DTS-HD MA + DTS:X"), 8, "").Track.GetAudioCodec())
+	tests.Equals(t, "dts-hd-hra", rankedAudio(0, "A_DTS", ffprobeCodecName("A_DTS", "DTS-HD HRA"), 6, "").Track.GetAudioCodec())
+	tests.Equals(t, "dts", rankedAudio(0, "A_DTS", ffprobeCodecName("A_DTS", "DTS-ES"), 6, "").Track.GetAudioCodec())
+
+	// Track names, for the native prober
+	named := rankedAudio(0, "A_DTS", "DTS", 6, "")
+	named.Track.Properties.TrackName = "English DTS-HD MA 5.1"
+	tests.Equals(t, "dts-hd-ma", named.Track.GetAudioCodec())
+	named = rankedAudio(0, "A_TRUEHD", "TrueHD", 8, "")
+	named.Track.Properties.TrackName = "TrueHD Atmos 7.1"
+	tests.Equals(t, "truehd-atmos", named.Track.GetAudioCodec())
+}
+
+func TestGetBestAudioUsesTheAudioCodecsRanking(t *testing.T) {
+	aac := rankedAudio(0, "A_AAC", "AAC", 2, "")
+	atmos := rankedAudio(1, "A_TRUEHD", "TrueHD Atmos", 8, "")
+	ac3 := rankedAudio(2, "A_AC3", "AC-3", 6, "")
+	tracks := TracksController{Audios: Tracks{ac3, atmos, aac}}
+
+	audio, err := tracks.GetBestAudio("eng")
+	tests.Ok(t, err)
+	tests.Equals(t, aac.Track, audio.Track)
+
+	tracks.Preferences.AudioCodecs = AudioPresets["archival"]
+	audio, err = tracks.GetBestAudio("eng")
+	tests.Ok(t, err)
+	tests.Equals(t, atmos.Track, audio.Track)
+
+	tracks.Preferences.AudioCodecs = []string{"ac3"}
+	audio, err = tracks.GetBestAudio("eng")
+	tests.Ok(t, err)
+	tests.Equals(t, ac3.Track, audio.Track)
+}
+
+func TestGetBestAudioDecidesBetweenTheSameCodecByChannelsAndBitrate(t *testing.T) {
+	stereo := rankedAudio(0, "A_AC3", "AC-3", 2, "448000")
+	surround := rankedAudio(1, "A_AC3", "AC-3", 6, "384000")
+	better := rankedAudio(2, "A_AC3", "AC-3", 6, "640000")
+	tracks := TracksController{Audios: Tracks{stereo, surround, better}}
+
+	audio, err := tracks.GetBestAudio("eng")
+	tests.Ok(t, err)
+	tests.Equals(t, better.Track, audio.Track)
+
+	tracks.Audios = Tracks{stereo, surround}
+	audio, err = tracks.GetBestAudio("eng")
+	tests.Ok(t, err)
+	tests.Equals(t, surround.Track, audio.Track)
+}
+
+func TestParseAudioCodecs(t *testing.T) {
+	codecs, err := ParseAudioCodecs("TrueHD-Atmos, flac,aac")
+	tests.Ok(t, err)
+	tests.Equals(t, []string{"truehd-atmos", "flac", "aac"}, codecs)
+
+	_, err = ParseAudioCodecs("aac,mp4")
+	tests.Equals(t, `unknown audio codec "mp4"`, err.Error())
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+AudioRoles are the audio tracks that can be taken for each language:
+
+  - main: the best track (see GetBestAudio)
+  - surround: the best track with more than two channels
+  - stereo: the best track with one or two channels, for devices without
+    surround sound
+  - commentary: all the commentary tracks
+  - description: all the audio description tracks
+
+Only main tracks (see Track.Kind) are taken by the main, surround and stereo
+roles.
+*/
+var AudioRoles = []string{"main", "surround", "stereo", KindCommentary, KindDescription}
+
+/*
+ParseAudioSet parses a comma separated list of audio roles, like
+"main,stereo,commentary"
+*/
+func ParseAudioSet(value string) (roles []string, err error) {
+	for _, role := range strings.Split(value, ",") {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !containsString(AudioRoles, role) {
+			return nil, fmt.Errorf("unknown audio role %q, expected %s", role, strings.Join(AudioRoles, ", "))
+		}
+		roles = append(roles, role)
+	}
+
+	return
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+GetAudioSet returns the audio tracks of the given language for each one of the
+roles of the audio set (just the main one by default), in the roles order and
+without repeating tracks. An AudioRoleNotFoundError is returned when the
+language has audio tracks but none of them fits the roles.
+*/
+func (t *TracksController) GetAudioSet(language string) (tracks Tracks, err error) {
+	audios := t.Audios.filterLanguage(language)
+
+	for _, role := range t.Preferences.audioSet() {
+		for _, audio := range t.Preferences.audioRole(role, audios) {
+			if !tracks.contains(audio) {
+				tracks = append(tracks, audio)
+			}
+		}
+	}
+
+	if len(audios) == 0 {
+		return nil, &LanguageNotFoundError{Type: "audio", Language: language}
+	}
+	if len(tracks) == 0 {
+		return nil, &AudioRoleNotFoundError{Language: language, Roles: t.Preferences.audioSet()}
+	}
+
+	return
+}
+
+// audioRole returns the tracks of the given role among the audios of the same
+// language
+func (preferences Preferences) audioRole(role string, audios Tracks) Tracks {
+	regular := audios.Filter(kindFilter(KindMain))
+
+	switch role {
+	case "main":
+		return preferences.bestAudio(regular)
+	case "surround", "stereo":
+		return preferences.bestAudio(regular.Filter(func(track TrackController) bool {
+			return fitsRole(role, track)
+		}))
+	case KindCommentary, KindDescription:
+		return audios.Filter(kindFilter(role))
+	}
+
+	return nil
+}
+
+// fitsRole tells whether the track can be taken by the given role
+func fitsRole(role string, track TrackController) bool {
+	channels := track.Track.Properties.AudioChannels
+	kind := track.Track.Kind()
+
+	switch role {
+	case "main":
+		return kind == KindMain
+	case "surround":
+		return kind == KindMain && channels > 2
+	case "stereo":
+		return kind == KindMain && channels > 0 && channels <= 2
+	}
+
+	return kind == role
+}
+
+// bestAudio returns the best of the given audios (see sortAudios), if any
+func (preferences Preferences) bestAudio(audios Tracks) Tracks {
+	if len(audios) == 0 {
+		return nil
+	}
+
+	sorted := append(Tracks{}, audios...)
+	preferences.sortAudios(sorted)
+
+	return sorted[:1]
+}
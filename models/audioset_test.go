@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestGetBestAudiosTakesTheAudioSetOfEachLanguage(t *testing.T) {
+	atmos := rankedAudio(0, "A_TRUEHD", "TrueHD Atmos", 8, "")
+	surround := rankedAudio(1, "A_AC3", "AC-3", 6, "")
+	stereo := rankedAudio(2, "A_AAC", "AAC", 2, "")
+	commentary := rankedAudio(3, "A_AAC", "AAC", 2, "")
+	commentary.Track.Properties.Commentary = true
+	spanish := rankedAudio(4, "A_AC3", "AC-3", 6, "")
+	spanish.Track.Properties.Language = "spa"
+
+	tracks := TracksController{
+		Audios: Tracks{commentary, stereo, spanish, surround, atmos},
+		Preferences: Preferences{
+			AudioCodecs: AudioPresets["archival"],
+			AudioSet:    []string{"main", "surround", "stereo", "commentary"},
+		},
+	}
+
+	audios, err := tracks.GetBestAudios([]string{"eng", "spa", "en"})
+
+	tests.Ok(t, err)
+	// The best surround track is the main one
+	tests.Equals(t, Tracks{atmos, stereo, commentary, spanish}, audios)
+
+	tracks.Preferences.AudioSet = []string{"stereo", "main"}
+	audios, err = tracks.GetBestAudios([]string{"spa", "eng"})
+
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{spanish, stereo, atmos}, audios)
+}
+
+func TestGetBestAudioExcludesCommentaries(t *testing.T) {
+	commentary := rankedAudio(0, "A_AAC", "AAC", 2, "")
+	commentary.Track.Properties.TrackName = "Director's commentary"
+	regular := rankedAudio(1, "A_MPEG/L3", "MP3", 2, "")
+	tracks := TracksController{Audios: Tracks{commentary, regular}}
+
+	audio, err := tracks.GetBestAudio("eng")
+	tests.Ok(t, err)
+	tests.Equals(t, regular, audio)
+
+	tracks.Audios = Tracks{commentary}
+	_, err = tracks.GetBestAudio("eng")
+	tests.Equals(t, &LanguageNotFoundError{Type: "audio", Language: "eng"}, err)
+
+	// Unless asked for
+	tracks.Preferences.IncludeKinds = []string{KindCommentary}
+	audios, err := tracks.GetBestAudios([]string{"eng"})
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{commentary}, audios)
+
+	tracks.Preferences.AudioSet = []string{"surround"}
+	tracks.Preferences.IncludeKinds = nil
+	_, err = tracks.GetBestAudios([]string{"eng"})
+	tests.Equals(t, &AudioRoleNotFoundError{Language: "eng", Roles: []string{"surround"}}, err)
+
+	_, err = tracks.GetBestAudios([]string{"spa"})
+	tests.Equals(t, &LanguageNotFoundError{Type: "audio", Language: "spa"}, err)
+}
+
+func TestParseAudioSet(t *testing.T) {
+	roles, err := ParseAudioSet("Main, stereo")
+	tests.Ok(t, err)
+	tests.Equals(t, []string{"main", "stereo"}, roles)
+
+	_, err = ParseAudioSet("main,mono")
+	tests.Equals(t, `unknown audio role "mono", expected main, surround, stereo, commentary, description`, err.Error())
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+/*
+ProbeVersion identifies the structure of the information returned by the
+probers. Increase it every time it changes, so any cached result is discarded.
+*/
+const ProbeVersion = "7"
+
+type cacheEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+	Version string `json:"version"`
+	Info    Info   `json:"info"`
+}
+
+/*
+CachedProber stores the information obtained by another prober on disk, so
+files are only identified again when they change (their size or modification
+time differ) or when the prober version changes.
+*/
+type CachedProber struct {
+	Prober  Prober
+	Dir     string
+	Version string
+}
+
+/*
+DefaultCacheDir returns the directory used to store the probe cache, under the
+user's cache directory.
+*/
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "remuxing", "probes"), nil
+}
+
+/*
+Probe the given file, returning the cached information if it's still valid
+*/
+func (prober CachedProber) Probe(file string) (information Info, err error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return prober.Prober.Probe(file)
+	}
+
+	// Let the prober report any error related to the file itself
+	fi, err := os.Stat(path)
+	if err != nil {
+		return prober.Prober.Probe(file)
+	}
+
+	entry := cacheEntry{
+		Path:    path,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UnixNano(),
+		Version: ProbeVersion + ":" + prober.Version,
+	}
+
+	if cached, ok := prober.load(entry); ok {
+		cached.FileName = file
+
+		return cached, nil
+	}
+
+	if information, err = prober.Prober.Probe(file); err != nil {
+		return
+	}
+
+	entry.Info = information
+	// Not being able to cache a result should never stop the remuxing
+	_ = prober.save(entry)
+
+	return information, nil
+}
+
+/*
+Clear removes all the cached information
+*/
+func (prober CachedProber) Clear() error {
+	return os.RemoveAll(prober.Dir)
+}
+
+// entryFile returns the cache file for the given path. There's only one entry
+// per path, so outdated entries are overwritten instead of piling up.
+func (prober CachedProber) entryFile(path string) string {
+	sum := sha1.Sum([]byte(path))
+
+	return filepath.Join(prober.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (prober CachedProber) load(expected cacheEntry) (information Info, ok bool) {
+	data, err := ioutil.ReadFile(prober.entryFile(expected.Path))
+	if err != nil {
+		return
+	}
+
+	entry := cacheEntry{}
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return
+	}
+
+	if entry.Path != expected.Path ||
+		entry.Size != expected.Size ||
+		entry.ModTime != expected.ModTime ||
+		entry.Version != expected.Version {
+		return
+	}
+
+	return entry.Info, true
+}
+
+func (prober CachedProber) save(entry cacheEntry) error {
+	if err := os.MkdirAll(prober.Dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so concurrent runs never read half
+	// written entries
+	tmp, err := ioutil.TempFile(prober.Dir, "entry")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), prober.entryFile(entry.Path))
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+type countingProber struct {
+	calls *int
+}
+
+func (prober countingProber) Probe(file string) (Info, error) {
+	*prober.calls++
+
+	return FixtureProber{Dir: "testdata"}.Probe("movie.mkv")
+}
+
+func TestCachedProberOnlyProbesChangedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remuxing")
+	tests.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.mkv")
+	tests.Ok(t, ioutil.WriteFile(input, []byte("whatever"), 0644))
+
+	calls := 0
+	prober := CachedProber{
+		Prober:  countingProber{&calls},
+		Dir:     filepath.Join(dir, "cache"),
+		Version: "test",
+	}
+
+	info, err := prober.Probe(input)
+	tests.Ok(t, err)
+	tests.Equals(t, 1, calls)
+
+	cached, err := prober.Probe(input)
+	tests.Ok(t, err)
+	tests.Equals(t, 1, calls)
+	tests.Equals(t, info.Container, cached.Container)
+	tests.Equals(t, input, cached.FileName)
+	tests.Equals(t, len(info.Tracks), len(cached.Tracks))
+	tests.Equals(t, *info.Tracks[2].Track, *cached.Tracks[2].Track)
+
+	// Modifying the file invalidates the cached entry
+	later := time.Now().Add(time.Hour)
+	tests.Ok(t, os.Chtimes(input, later, later))
+
+	_, err = prober.Probe(input)
+	tests.Ok(t, err)
+	tests.Equals(t, 2, calls)
+
+	// And so does changing the prober
+	prober.Version = "other"
+	_, err = prober.Probe(input)
+	tests.Ok(t, err)
+	tests.Equals(t, 3, calls)
+
+	tests.Ok(t, prober.Clear())
+	_, err = prober.Probe(input)
+	tests.Ok(t, err)
+	tests.Equals(t, 4, calls)
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Minimum amount of letters needed to classify a text
+const classifierMinLetters = 100
+
+var (
+	languageProfiles     map[string]trigramProfile
+	languageProfilesOnce sync.Once
+)
+
+// trigramProfile holds the (normalized) frequency of each trigram of a text
+type trigramProfile map[string]float64
+
+// newTrigramProfile builds the profile of the given text, using the trigrams
+// of each word surrounded by spaces (so " th", "the", "he ")
+func newTrigramProfile(text string) trigramProfile {
+	profile := trigramProfile{}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			profile[string(runes[i:i+3])]++
+		}
+	}
+
+	var norm float64
+	for _, count := range profile {
+		norm += count * count
+	}
+	norm = math.Sqrt(norm)
+
+	for trigram := range profile {
+		profile[trigram] /= norm
+	}
+
+	return profile
+}
+
+// similarity is the cosine similarity between two normalized profiles
+func (profile trigramProfile) similarity(other trigramProfile) (similarity float64) {
+	for trigram, weight := range profile {
+		similarity += weight * other[trigram]
+	}
+
+	return
+}
+
+func loadLanguageProfiles() map[string]trigramProfile {
+	languageProfilesOnce.Do(func() {
+		languageProfiles = map[string]trigramProfile{}
+		for language, sample := range languageSamples {
+			languageProfiles[language] = newTrigramProfile(sample)
+		}
+	})
+
+	return languageProfiles
+}
+
+// classifyText guesses the language of the given text, returning a
+// confidence between 0 and 1 based on how far the best match is from the
+// second one. Texts too short to be classified return an empty language.
+func classifyText(text string) (language string, confidence float64) {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < classifierMinLetters {
+		return "", 0
+	}
+
+	profile := newTrigramProfile(text)
+
+	var best, second float64
+	for lang, languageProfile := range loadLanguageProfiles() {
+		similarity := profile.similarity(languageProfile)
+		switch {
+		case similarity > best:
+			language, best, second = lang, similarity, best
+		case similarity > second:
+			second = similarity
+		}
+	}
+
+	if best == 0 {
+		return "", 0
+	}
+
+	return language, (best - second) / best
+}
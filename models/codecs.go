@@ -0,0 +1,86 @@
+package models
+
+// Codec names as reported by mkvmerge, indexed by their Matroska codec ID.
+var codecNames = map[string]string{
+	"V_AV1":            "AV1",
+	"V_MPEGH/ISO/HEVC": "MPEG-H/HEVC/h.265",
+	"V_MPEG4/ISO/AVC":  "MPEG-4p10/AVC/h.264",
+	"V_MPEG4/ISO/ASP":  "MPEG-4p2",
+	"V_MPEG2":          "MPEG-1/2",
+	"V_MPEG1":          "MPEG-1/2",
+	"V_VP8":            "VP8",
+	"V_VP9":            "VP9",
+	"V_MS/VFW/FOURCC":  "VfW",
+	"A_AAC":            "AAC",
+	"A_AC3":            "AC-3",
+	"A_EAC3":           "E-AC-3",
+	"A_DTS":            "DTS",
+	"A_TRUEHD":         "TrueHD",
+	"A_FLAC":           "FLAC",
+	"A_OPUS":           "Opus",
+	"A_VORBIS":         "Vorbis",
+	"A_MPEG/L3":        "MP3",
+	"A_MPEG/L2":        "MP2",
+	"A_ALAC":           "ALAC",
+	"A_PCM/INT/LIT":    "PCM",
+	"A_PCM/INT/BIG":    "PCM",
+	"A_PCM/FLOAT/IEEE": "PCM",
+	"S_TEXT/UTF8":      "SubRip/SRT",
+	"S_TEXT/ASS":       "SubStationAlpha",
+	"S_TEXT/SSA":       "SubStationAlpha",
+	"S_TEXT/WEBVTT":    "WebVTT",
+	"S_HDMV/PGS":       "HDMV PGS",
+	"S_HDMV/TEXTST":    "HDMV TextST",
+	"S_VOBSUB":         "VobSub",
+	"S_DVBSUB":         "DVBSUB",
+	"S_KATE":           "Kate",
+	"V_UNCOMPRESSED":   "Uncompressed",
+}
+
+// Matroska codec IDs for the codec names used by ffprobe.
+var ffprobeCodecs = map[string]string{
+	"av1":               "V_AV1",
+	"hevc":              "V_MPEGH/ISO/HEVC",
+	"h264":              "V_MPEG4/ISO/AVC",
+	"mpeg4":             "V_MPEG4/ISO/ASP",
+	"mpeg2video":        "V_MPEG2",
+	"mpeg1video":        "V_MPEG1",
+	"vp8":               "V_VP8",
+	"vp9":               "V_VP9",
+	"aac":               "A_AAC",
+	"ac3":               "A_AC3",
+	"eac3":              "A_EAC3",
+	"dts":               "A_DTS",
+	"truehd":            "A_TRUEHD",
+	"flac":              "A_FLAC",
+	"opus":              "A_OPUS",
+	"vorbis":            "A_VORBIS",
+	"mp3":               "A_MPEG/L3",
+	"mp2":               "A_MPEG/L2",
+	"alac":              "A_ALAC",
+	"pcm_s16le":         "A_PCM/INT/LIT",
+	"pcm_s24le":         "A_PCM/INT/LIT",
+	"pcm_s32le":         "A_PCM/INT/LIT",
+	"pcm_s16be":         "A_PCM/INT/BIG",
+	"pcm_s24be":         "A_PCM/INT/BIG",
+	"pcm_f32le":         "A_PCM/FLOAT/IEEE",
+	"subrip":            "S_TEXT/UTF8",
+	"srt":               "S_TEXT/UTF8",
+	"ass":               "S_TEXT/ASS",
+	"ssa":               "S_TEXT/SSA",
+	"webvtt":            "S_TEXT/WEBVTT",
+	"mov_text":          "S_TEXT/UTF8",
+	"hdmv_pgs_subtitle": "S_HDMV/PGS",
+	"dvd_subtitle":      "S_VOBSUB",
+	"dvb_subtitle":      "S_DVBSUB",
+}
+
+// codecName returns the mkvmerge codec name for the given codec ID, falling
+// back to the ID itself when it's unknown.
+func codecName(id string) string {
+	if name, ok := codecNames[id]; ok {
+		return name
+	}
+
+	return id
+}
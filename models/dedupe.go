@@ -0,0 +1,52 @@
+package models
+
+// trackGroup identifies the duplicated tracks, when no languages are given
+type trackGroup struct {
+	language string
+	kind     string
+	forced   bool
+}
+
+// groupTracks groups the tracks by language and kind (and forced flag, for
+// subtitles), keeping the groups in order of appearance
+func groupTracks(tracks Tracks) (groups []Tracks) {
+	positions := map[trackGroup]int{}
+	for _, track := range tracks {
+		group := trackGroup{
+			language: track.Track.GetLanguage(),
+			kind:     track.Track.Kind(),
+			forced:   track.Track.Type == "subtitles" && track.Track.Properties.Forced,
+		}
+
+		position, ok := positions[group]
+		if !ok {
+			position = len(groups)
+			positions[group] = position
+			groups = append(groups, nil)
+		}
+		groups[position] = append(groups[position], track)
+	}
+
+	return
+}
+
+// dedupeAudios returns the best audio of each language and kind
+func (t *TracksController) dedupeAudios() (audios Tracks) {
+	for _, group := range groupTracks(t.Audios) {
+		audios = append(audios, t.Preferences.bestAudio(group)...)
+	}
+
+	return
+}
+
+// dedupeSubtitles returns the best subtitle of each language and kind, and of
+// each forced flag
+func (t *TracksController) dedupeSubtitles() (subtitles Tracks) {
+	for _, group := range groupTracks(t.Subtitles) {
+		group = append(Tracks{}, group...)
+		t.Preferences.sortSubtitles(group)
+		subtitles = append(subtitles, group[0])
+	}
+
+	return
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestDedupeKeepsTheBestTrackOfEachLanguageAndKind(t *testing.T) {
+	english := rankedAudio(0, "A_AC3", "AC-3", 6, "")
+	better := rankedAudio(1, "A_AAC", "AAC", 2, "")
+	commentary := rankedAudio(2, "A_AAC", "AAC", 2, "")
+	commentary.Track.Properties.Commentary = true
+	spanish := rankedAudio(3, "A_AC3", "AC-3", 6, "")
+	spanish.Track.Properties.Language = "spa"
+
+	full := rankedSubtitle(4, "S_HDMV/PGS", false, "1200", "")
+	srt := rankedSubtitle(5, "S_TEXT/UTF8", false, "1100", "")
+	forced := rankedSubtitle(6, "S_TEXT/UTF8", true, "10", "")
+	sdh := rankedSubtitle(7, "S_TEXT/UTF8", false, "1300", "")
+	sdh.Track.Properties.HearingImpaired = true
+
+	tracks := TracksController{
+		Audios:    Tracks{english, spanish, commentary, better},
+		Subtitles: Tracks{full, forced, sdh, srt},
+	}
+
+	audios, err := tracks.GetBestAudios(nil)
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{english, spanish, commentary, better}, audios)
+	tests.Equals(t, Tracks{full, forced, sdh, srt}, tracks.GetBestSubtitles(nil))
+
+	tracks.Preferences.Dedupe = true
+
+	audios, err = tracks.GetBestAudios(nil)
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{better, spanish, commentary}, audios)
+	tests.Equals(t, Tracks{srt, forced, sdh}, tracks.GetBestSubtitles(nil))
+}
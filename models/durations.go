@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+DurationMismatch describes an input (or one of its tracks, if Track is set)
+whose duration differs from the video one
+*/
+type DurationMismatch struct {
+	Input     *Info
+	Track     *Track
+	Duration  time.Duration
+	Reference time.Duration
+}
+
+/*
+GetDifference returns how much longer (or shorter, if negative) the mismatching
+input or track is
+*/
+func (mismatch DurationMismatch) GetDifference() time.Duration {
+	return mismatch.Duration - mismatch.Reference
+}
+
+func (mismatch DurationMismatch) String() string {
+	name := mismatch.Input.FileName
+	if mismatch.Track != nil {
+		name = fmt.Sprintf("%s (track %d)", name, mismatch.Track.ID)
+	}
+
+	sign := "+"
+	if mismatch.GetDifference() < 0 {
+		sign = ""
+	}
+
+	return fmt.Sprintf("%s lasts %s, %s%s than the video", name, mismatch.Duration, sign, mismatch.GetDifference())
+}
+
+/*
+DurationMismatchError is returned when the selected tracks come from inputs
+whose duration differ from the video one more than the allowed tolerance
+*/
+type DurationMismatchError struct {
+	Video      *TrackController
+	Tolerance  time.Duration
+	Mismatches []DurationMismatch
+}
+
+func (err *DurationMismatchError) Error() string {
+	lines := []string{fmt.Sprintf(
+		"durations differ from the video in %s more than %s:",
+		err.Video.Input.FileName,
+		err.Tolerance,
+	)}
+	for _, mismatch := range err.Mismatches {
+		lines = append(lines, "  - "+mismatch.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+/*
+CheckDurations compares the duration of the inputs of the given tracks (and of
+the audio tracks themselves, when their statistics tags are available) with
+the video one, returning a *DurationMismatchError if any of them differs more
+than the given tolerance. The detected sync offsets and frame rate corrections are
+taken into account, as they change the resulting durations.
+
+Inputs with just subtitles are not checked, as their duration depends on their
+last subtitle.
+*/
+func CheckDurations(video *TrackController, tolerance time.Duration, selections ...Tracks) error {
+	reference := inputDuration(video.Input)
+	trackReference := video.Track.GetDuration()
+	if trackReference == 0 {
+		trackReference = reference
+	}
+
+	err := &DurationMismatchError{Video: video, Tolerance: tolerance}
+	checked := map[*Info]bool{video.Input: true}
+
+	for _, tracks := range selections {
+		for _, track := range tracks {
+			if track.Track.Type == "audio" && trackReference > 0 {
+				if duration := track.Track.GetDuration(); duration > 0 && exceeds(synced(track.Input, duration)-trackReference, tolerance) {
+					err.Mismatches = append(err.Mismatches, DurationMismatch{
+						Input:     track.Input,
+						Track:     track.Track,
+						Duration:  synced(track.Input, duration),
+						Reference: trackReference,
+					})
+				}
+			}
+
+			if checked[track.Input] || !hasTimedTracks(track.Input) {
+				continue
+			}
+			checked[track.Input] = true
+
+			if duration := inputDuration(track.Input); duration > 0 && reference > 0 && exceeds(synced(track.Input, duration)-reference, tolerance) {
+				err.Mismatches = append(err.Mismatches, DurationMismatch{
+					Input:     track.Input,
+					Duration:  synced(track.Input, duration),
+					Reference: reference,
+				})
+			}
+		}
+	}
+
+	if len(err.Mismatches) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func inputDuration(input *Info) time.Duration {
+	if input == nil {
+		return 0
+	}
+
+	return input.GetDuration()
+}
+
+// synced returns the duration the input tracks have once muxed, as their
+// offset and frame rate correction change it
+func synced(input *Info, duration time.Duration) time.Duration {
+	if input == nil || input.Sync == nil {
+		return duration
+	}
+
+	return input.Sync.stretch(duration) + input.Sync.Offset
+}
+
+// hasTimedTracks checks whether the input has any video or audio track, whose
+// duration can be compared with the video one.
+func hasTimedTracks(input *Info) bool {
+	if input == nil {
+		return false
+	}
+
+	for _, track := range input.Tracks {
+		if track.Track.Type == "video" || track.Track.Type == "audio" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func exceeds(difference, tolerance time.Duration) bool {
+	return difference > tolerance || difference < -tolerance
+}
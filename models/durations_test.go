@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func durationInput(name string, seconds uint64, types ...string) *Info {
+	info := &Info{FileName: name}
+	info.Container.Properties.Duration = seconds * uint64(time.Second)
+	for i, kind := range types {
+		info.Tracks = append(info.Tracks, TrackController{Track: &Track{ID: uint(i), Type: kind}})
+	}
+
+	return info
+}
+
+func TestCheckDurationsAcceptsInputsWithinTolerance(t *testing.T) {
+	video := durationInput("video.mkv", 5400, "video", "audio")
+	audio := durationInput("audio.mka", 5401, "audio")
+
+	err := CheckDurations(
+		&TrackController{Input: video, Track: video.Tracks[0].Track},
+		2*time.Second,
+		Tracks{TrackController{Input: audio, Track: audio.Tracks[0].Track}},
+	)
+
+	tests.Ok(t, err)
+}
+
+func TestCheckDurationsReportsEveryMismatchingInput(t *testing.T) {
+	video := durationInput("video.mkv", 5400, "video")
+	short := durationInput("short.mka", 5300, "audio")
+	long := durationInput("long.mkv", 5460, "video", "audio", "subtitles")
+	subs := durationInput("subs.mks", 4000, "subtitles")
+
+	err := CheckDurations(
+		&TrackController{Input: video, Track: video.Tracks[0].Track},
+		2*time.Second,
+		Tracks{
+			TrackController{Input: short, Track: short.Tracks[0].Track},
+			TrackController{Input: long, Track: long.Tracks[1].Track},
+		},
+		Tracks{
+			TrackController{Input: long, Track: long.Tracks[2].Track},
+			TrackController{Input: subs, Track: subs.Tracks[0].Track},
+		},
+	)
+
+	mismatch, ok := err.(*DurationMismatchError)
+	tests.Assert(t, ok, "expected a *DurationMismatchError, got %#v", err)
+	tests.Equals(t, 2, len(mismatch.Mismatches))
+	tests.Equals(t, "short.mka", mismatch.Mismatches[0].Input.FileName)
+	tests.Equals(t, -100*time.Second, mismatch.Mismatches[0].GetDifference())
+	tests.Equals(t, "long.mkv", mismatch.Mismatches[1].Input.FileName)
+	tests.Equals(t, time.Minute, mismatch.Mismatches[1].GetDifference())
+}
+
+func TestCheckDurationsComparesAudioTracksStatistics(t *testing.T) {
+	video := durationInput("video.mkv", 5400, "video")
+	video.Tracks[0].Track.Properties.TagDuration = "01:30:00.041000000"
+	other := durationInput("other.mkv", 5400, "video", "audio")
+	other.Tracks[1].Track.Properties.TagDuration = "01:29:55.000000000"
+
+	err := CheckDurations(
+		&TrackController{Input: video, Track: video.Tracks[0].Track},
+		time.Second,
+		Tracks{TrackController{Input: other, Track: other.Tracks[1].Track}},
+	)
+
+	mismatch, ok := err.(*DurationMismatchError)
+	tests.Assert(t, ok, "expected a *DurationMismatchError, got %#v", err)
+	tests.Equals(t, 1, len(mismatch.Mismatches))
+	tests.Equals(t, other.Tracks[1].Track, mismatch.Mismatches[0].Track)
+	tests.Equals(t, -5041*time.Millisecond, mismatch.Mismatches[0].GetDifference())
+}
+
+func TestCheckDurationsKeepsSubsecondPrecision(t *testing.T) {
+	video := durationInput("video.mkv", 5400, "video")
+	audio := durationInput("audio.mka", 5400, "audio")
+	audio.Container.Properties.Duration += uint64(800 * time.Millisecond)
+	tracks := Tracks{TrackController{Input: audio, Track: audio.Tracks[0].Track}}
+
+	tests.Ok(t, CheckDurations(&TrackController{Input: video, Track: video.Tracks[0].Track}, time.Second, tracks))
+
+	err := CheckDurations(&TrackController{Input: video, Track: video.Tracks[0].Track}, 500*time.Millisecond, tracks)
+
+	mismatch, ok := err.(*DurationMismatchError)
+	tests.Assert(t, ok, "expected a *DurationMismatchError, got %#v", err)
+	tests.Equals(t, 800*time.Millisecond, mismatch.Mismatches[0].GetDifference())
+}
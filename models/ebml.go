@@ -0,0 +1,191 @@
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// EBML sizes with all their bits set mean the size of the element is unknown
+const ebmlUnknownSize = -1
+
+var (
+	errInvalidVint = errors.New("invalid EBML variable size integer")
+	errInvalidSize = errors.New("EBML element bigger than its file")
+)
+
+// Biggest length of a slice
+const maxInt = int64(^uint(0) >> 1)
+
+type ebmlElement struct {
+	ID   uint32
+	Data []byte
+}
+
+type ebmlHeader struct {
+	ID         uint32
+	Size       int64
+	HeaderSize int64
+}
+
+// readVint reads an EBML variable size integer, returning its value (with or
+// without its length marker) and its length in bytes.
+func readVint(r io.Reader, keepMarker bool) (value uint64, length int, err error) {
+	first := make([]byte, 1)
+	if _, err = io.ReadFull(r, first); err != nil {
+		return
+	}
+
+	length = 1
+	for mask := byte(0x80); first[0]&mask == 0; mask >>= 1 {
+		if mask == 1 {
+			return 0, 0, errInvalidVint
+		}
+		length++
+	}
+
+	value = uint64(first[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> uint(length))
+	}
+
+	rest := make([]byte, length-1)
+	if _, err = io.ReadFull(r, rest); err != nil {
+		return
+	}
+
+	for _, b := range rest {
+		value = value<<8 | uint64(b)
+	}
+
+	return
+}
+
+// readEBMLHeader reads the id and size of the next element.
+func readEBMLHeader(r io.Reader) (header ebmlHeader, err error) {
+	id, idLength, err := readVint(r, true)
+	if err != nil {
+		return
+	}
+
+	size, sizeLength, err := readVint(r, false)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return
+	}
+
+	header.ID = uint32(id)
+	header.HeaderSize = int64(idLength + sizeLength)
+	header.Size = int64(size)
+	if size == uint64(1)<<uint(7*sizeLength)-1 {
+		header.Size = ebmlUnknownSize
+	}
+
+	return
+}
+
+// readEBMLData reads the data of an element, checking its size is within the
+// file before allocating it, as sizes are given by the (maybe corrupt) file.
+func readEBMLData(r io.ReadSeeker, size int64) (data []byte, err error) {
+	current, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+	if _, err = r.Seek(current, io.SeekStart); err != nil {
+		return
+	}
+
+	if size < 0 || size > end-current || size > maxInt {
+		return nil, errInvalidSize
+	}
+
+	data = make([]byte, size)
+	_, err = io.ReadFull(r, data)
+
+	return
+}
+
+// ebmlChildren splits the data of a master element into its children.
+func ebmlChildren(data []byte) (children []ebmlElement, err error) {
+	r := &byteCounter{data: data}
+
+	for r.pos < len(data) {
+		header, err := readEBMLHeader(r)
+		if err != nil {
+			return children, err
+		}
+
+		// Unknown sized children can only be the last ones, and sizes bigger
+		// than their parent are cut (compared as int64, as they may overflow)
+		size := len(data) - r.pos
+		if header.Size != ebmlUnknownSize && header.Size < int64(size) {
+			size = int(header.Size)
+		}
+
+		children = append(children, ebmlElement{
+			ID:   header.ID,
+			Data: data[r.pos : r.pos+size],
+		})
+		r.pos += size
+	}
+
+	return
+}
+
+func (element ebmlElement) uint() uint64 {
+	var value uint64
+	for _, b := range element.Data {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+func (element ebmlElement) float() float64 {
+	switch len(element.Data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(element.Data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(element.Data))
+	}
+
+	return 0
+}
+
+func (element ebmlElement) string() string {
+	// Strings may be zero padded
+	for i, b := range element.Data {
+		if b == 0 {
+			return string(element.Data[:i])
+		}
+	}
+
+	return string(element.Data)
+}
+
+func (element ebmlElement) children() ([]ebmlElement, error) {
+	return ebmlChildren(element.Data)
+}
+
+type byteCounter struct {
+	data []byte
+	pos  int
+}
+
+func (r *byteCounter) Read(p []byte) (n int, err error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, r.data[r.pos:])
+	r.pos += n
+
+	return
+}
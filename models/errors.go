@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Returned by the probers when they can't read the given file format
+var errUnsupportedContainer = errors.New("unsupported container")
+
+/*
+InputNotFoundError is returned when an input file does not exist
+*/
+type InputNotFoundError struct {
+	Input    string
+	Position int
+}
+
+func (err *InputNotFoundError) Error() string {
+	return fmt.Sprintf("%s: input not found", err.Input)
+}
+
+/*
+UnsupportedContainerError is returned when the prober can't read an input's
+container format
+*/
+type UnsupportedContainerError struct {
+	Input    string
+	Position int
+}
+
+func (err *UnsupportedContainerError) Error() string {
+	return fmt.Sprintf("%s: unsupported container", err.Input)
+}
+
+/*
+ProbeError is returned when an input could not be identified for any other
+reason, like the prober tool failing or not being installed
+*/
+type ProbeError struct {
+	Input    string
+	Position int
+	Err      error
+}
+
+func (err *ProbeError) Error() string {
+	return fmt.Sprintf("%s: probe failed: %v", err.Input, err.Err)
+}
+
+/*
+InputErrors lists all the inputs that could not be identified, in the inputs
+order. Every error is an *InputNotFoundError, an *UnsupportedContainerError or
+a *ProbeError.
+*/
+type InputErrors []error
+
+func (errs InputErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	lines := []string{fmt.Sprintf("%d inputs could not be identified:", len(errs))}
+	for _, err := range errs {
+		lines = append(lines, "  - "+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+/*
+NoVideoTrackError is returned when none of the inputs has a video track
+*/
+type NoVideoTrackError struct{}
+
+func (err *NoVideoTrackError) Error() string {
+	return "no video track found in any of the inputs"
+}
+
+/*
+LanguageNotFoundError is returned when none of the inputs has a track of the
+given type ("audio", "subtitles") for a requested language
+*/
+type LanguageNotFoundError struct {
+	Type     string
+	Language string
+}
+
+func (err *LanguageNotFoundError) Error() string {
+	return fmt.Sprintf("no %s track found for language %q", err.Type, err.Language)
+}
+
+/*
+AudioRoleNotFoundError is returned when a requested language has audio tracks,
+but none of them fits the roles of the audio set (see AudioRoles)
+*/
+type AudioRoleNotFoundError struct {
+	Language string
+	Roles    []string
+}
+
+func (err *AudioRoleNotFoundError) Error() string {
+	return fmt.Sprintf("no audio track of language %q fits role %s", err.Language, strings.Join(err.Roles, ","))
+}
+
+// inputError converts a prober error to its typed error.
+func inputError(input string, position int, err error) error {
+	if os.IsNotExist(err) {
+		return &InputNotFoundError{Input: input, Position: position}
+	}
+
+	if err == errUnsupportedContainer {
+		return &UnsupportedContainerError{Input: input, Position: position}
+	}
+
+	return &ProbeError{Input: input, Position: position, Err: err}
+}
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Decision explains why a track was selected or rejected
+*/
+type Decision struct {
+	Input    string `json:"input"`
+	Position int    `json:"position"`
+	Track    uint   `json:"track"`
+	Type     string `json:"type"`
+	Codec    string `json:"codec"`
+	Language string `json:"language"`
+	Kind     string `json:"kind"`
+	Selected bool   `json:"selected"`
+	Reason   string `json:"reason"`
+	// Only set for video tracks
+	Score *float64 `json:"score,omitempty"`
+}
+
+/*
+Explain returns a decision for every track of all the inputs, given the tracks
+chosen with the same languages and preferences. Tracks missing from the
+controller tracks are reported as removed by the filters.
+*/
+func (t *TracksController) Explain(languages []string, video *TrackController, audios, subtitles Tracks) (decisions []Decision) {
+	scores := t.ScoreVideos()
+
+	for _, input := range t.Inputs {
+		for _, track := range input.Tracks {
+			decision := Decision{
+				Input:    input.FileName,
+				Position: input.Position + 1,
+				Track:    track.Track.ID,
+				Type:     track.Track.Type,
+				Codec:    track.Track.Codec,
+				Language: track.Track.GetLanguage(),
+				Kind:     track.Track.Kind(),
+			}
+
+			switch track.Track.Type {
+			case "video":
+				decision.Selected = video != nil && video.Track == track.Track
+				decision.Reason, decision.Score = t.explainVideo(track, scores)
+			case "audio":
+				decision.Selected = audios.contains(track)
+				decision.Reason = t.explainAudio(track, languages, audios)
+			case "subtitles":
+				decision.Selected = subtitles.contains(track)
+				decision.Reason = t.explainSubtitle(track, languages, subtitles)
+			}
+
+			decisions = append(decisions, decision)
+		}
+	}
+
+	return
+}
+
+func (t *TracksController) explainVideo(track TrackController, scores []VideoScore) (string, *float64) {
+	if !t.Videos.contains(track) {
+		return "removed by the -video filter", nil
+	}
+
+	best := scores[0]
+	for _, score := range scores {
+		if score.Video.Track != track.Track {
+			continue
+		}
+
+		total := score.Score
+		if score.Video.Track == best.Video.Track {
+			return fmt.Sprintf("highest score (%.2f)", total), &total
+		}
+
+		return fmt.Sprintf(
+			"%s than track %d of %s (score %.2f vs %.2f)",
+			videoDisadvantage(score, best),
+			best.Video.Track.ID,
+			best.Video.Input.FileName,
+			total,
+			best.Score,
+		), &total
+	}
+
+	return "", nil
+}
+
+// videoDisadvantage returns the property that made the video score lower than
+// the best one
+func videoDisadvantage(score, best VideoScore) string {
+	differences := []struct {
+		reason     string
+		difference float64
+	}{
+		{"lower resolution", best.Resolution - score.Resolution},
+		{"worse codec rank", best.Codec - score.Codec},
+		{"lower bit depth", best.BitDepth - score.BitDepth},
+		{"worse HDR format", best.HDR - score.HDR},
+		{"lower bitrate", best.Bitrate - score.Bitrate},
+		{"earlier input", best.Position - score.Position},
+	}
+
+	reason, max := "same score, earlier input", 0.0
+	for _, item := range differences {
+		if item.difference > max {
+			reason, max = item.reason, item.difference
+		}
+	}
+
+	return reason
+}
+
+func (t *TracksController) explainAudio(track TrackController, languages []string, audios Tracks) string {
+	if !t.Audios.contains(track) {
+		return "removed by the -audio filter"
+	}
+
+	if len(languages) == 0 {
+		if !t.Preferences.Dedupe {
+			return "no -languages given, all tracks are taken"
+		}
+
+		return t.explainDuplicate(track, groupTracks(t.Audios), audios, t.Preferences.audioAdvantage)
+	}
+
+	for _, language := range languages {
+		if !t.Audios.filterLanguage(language).contains(track) {
+			continue
+		}
+
+		set, _ := t.GetAudioSet(language)
+		if set.contains(track) && !audios.contains(track) {
+			// Nothing is selected when a language was not found
+			return fmt.Sprintf("best %s audio, but other languages were not found", language)
+		}
+		if set.contains(track) {
+			return fmt.Sprintf("best %s audio", language)
+		}
+
+		kind := track.Track.Kind()
+		if kind != KindMain && !containsString(t.Preferences.audioSet(), kind) {
+			return fmt.Sprintf("%s tracks are excluded (see -include-%s)", kind, kind)
+		}
+
+		roles := t.Preferences.audioSet()
+		if len(set) == 0 {
+			return fmt.Sprintf("no %s track fits role %s", language, strings.Join(roles, ","))
+		}
+
+		// Compared with the track taken by the first role it fits
+		for _, role := range roles {
+			if !fitsRole(role, track) {
+				continue
+			}
+			for _, best := range t.Preferences.audioRole(role, t.Audios.filterLanguage(language)) {
+				return explainAdvantage(best, t.Preferences.audioAdvantage(best, track))
+			}
+		}
+
+		return fmt.Sprintf("does not fit role %s", strings.Join(roles, ","))
+	}
+
+	return t.explainLanguage(track, languages)
+}
+
+func (t *TracksController) explainSubtitle(track TrackController, languages []string, subtitles Tracks) string {
+	if !t.Subtitles.contains(track) {
+		return "removed by the -subs filter"
+	}
+
+	if len(languages) == 0 {
+		if !t.Preferences.Dedupe {
+			return "no -languages given, all tracks are taken"
+		}
+
+		return t.explainDuplicate(track, groupTracks(t.Subtitles), subtitles, t.Preferences.subtitleAdvantage)
+	}
+
+	for _, language := range languages {
+		if !t.Subtitles.filterLanguage(language).contains(track) {
+			continue
+		}
+
+		if subtitles.contains(track) {
+			return fmt.Sprintf("best %s subtitles", language)
+		}
+
+		kind := track.Track.Kind()
+		if kind != KindMain && !containsString(t.Preferences.IncludeKinds, kind) {
+			return fmt.Sprintf("%s tracks are excluded (see -include-%s)", kind, kind)
+		}
+
+		// Just one forced and one non forced subtitles of each kind
+		for _, other := range subtitles {
+			if other.Track.Kind() == kind &&
+				other.Track.Properties.Forced == track.Track.Properties.Forced &&
+				t.Subtitles.filterLanguage(language).contains(other) {
+				limit := "one subtitle per language"
+				if track.Track.Properties.Forced {
+					limit = "forced limit"
+				}
+
+				return limit + ", " + explainAdvantage(other, t.Preferences.subtitleAdvantage(other, track))
+			}
+		}
+	}
+
+	return t.explainLanguage(track, languages)
+}
+
+// explainDuplicate explains why the track was not the best one of its group
+func (t *TracksController) explainDuplicate(
+	track TrackController,
+	groups []Tracks,
+	selected Tracks,
+	compare func(a, b TrackController) string,
+) string {
+	if selected.contains(track) {
+		return "best of its language and kind"
+	}
+
+	for _, group := range groups {
+		if !group.contains(track) {
+			continue
+		}
+		for _, best := range group {
+			if selected.contains(best) {
+				return "duplicate, " + explainAdvantage(best, compare(best, track))
+			}
+		}
+	}
+
+	return ""
+}
+
+// explainLanguage explains why the track does not match any of the languages
+func (t *TracksController) explainLanguage(track TrackController, languages []string) string {
+	for _, language := range languages {
+		if track.Track.MatchLanguage(language) > 0 {
+			return fmt.Sprintf("language mismatch, other tracks match %s better", language)
+		}
+	}
+
+	return fmt.Sprintf("language mismatch, %s not in %s", track.Track.GetLanguage(), strings.Join(languages, ","))
+}
+
+// explainAdvantage describes why the best track was preferred
+func explainAdvantage(best TrackController, reason string) string {
+	file := ""
+	if best.Input != nil {
+		file = " of " + best.Input.FileName
+	}
+
+	if reason == "" {
+		return fmt.Sprintf("same rank as track %d%s, which comes first", best.Track.ID, file)
+	}
+
+	return fmt.Sprintf("%s in track %d%s", reason, best.Track.ID, file)
+}
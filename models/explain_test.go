@@ -0,0 +1,120 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+// explainInputs builds one input per file name with the given tracks
+func explainInputs(files []string, tracks map[string]Tracks) (inputs []*Info) {
+	for position, file := range files {
+		input := &Info{FileName: file, Position: position}
+		for _, track := range tracks[file] {
+			track.Input = input
+			input.Tracks = append(input.Tracks, track)
+		}
+		inputs = append(inputs, input)
+	}
+
+	return
+}
+
+func TestExplainTellsWhyEachTrackWasRejected(t *testing.T) {
+	small := scoredVideo(0, "V_MPEG4/ISO/AVC", "1280x720", "")
+	big := scoredVideo(1, "V_MPEG4/ISO/AVC", "1920x1080", "")
+	aac := rankedAudio(2, "A_AAC", "AAC", 2, "")
+	ac3 := rankedAudio(3, "A_AC3", "AC-3", 6, "")
+	spanish := rankedAudio(4, "A_AAC", "AAC", 2, "")
+	spanish.Track.Properties.Language = "spa"
+	srt := rankedSubtitle(5, "S_TEXT/UTF8", true, "", "")
+	pgs := rankedSubtitle(6, "S_HDMV/PGS", true, "", "")
+	filtered := rankedSubtitle(7, "S_TEXT/UTF8", false, "", "")
+
+	tracks := TracksController{
+		Inputs: explainInputs([]string{"a.mkv", "b.mkv"}, map[string]Tracks{
+			"a.mkv": {small, aac, spanish, srt, filtered},
+			"b.mkv": {big, ac3, pgs},
+		}),
+	}
+	tracks.Videos = Tracks{tracks.Inputs[0].Tracks[0], tracks.Inputs[1].Tracks[0]}
+	tracks.Audios = Tracks{tracks.Inputs[0].Tracks[1], tracks.Inputs[0].Tracks[2], tracks.Inputs[1].Tracks[1]}
+	tracks.Subtitles = Tracks{tracks.Inputs[0].Tracks[3], tracks.Inputs[1].Tracks[2]}
+
+	languages := []string{"eng"}
+	video, err := tracks.GetBestVideo()
+	tests.Ok(t, err)
+	audios, err := tracks.GetBestAudios(languages)
+	tests.Ok(t, err)
+	subtitles := tracks.GetBestSubtitles(languages)
+
+	decisions := tracks.Explain(languages, video, audios, subtitles)
+	tests.Equals(t, 8, len(decisions))
+
+	reasons := map[uint]string{}
+	selected := map[uint]bool{}
+	for _, decision := range decisions {
+		reasons[decision.Track] = decision.Reason
+		if decision.Selected {
+			selected[decision.Track] = true
+		}
+	}
+
+	tests.Equals(t, map[uint]bool{1: true, 2: true, 5: true}, selected)
+	tests.Assert(t, decisions[0].Score != nil && decisions[4].Score == nil, "only videos are scored")
+	tests.Equals(t, "lower resolution than track 1 of b.mkv (score 2.98 vs 5.21)", reasons[0])
+	tests.Equals(t, "highest score (5.21)", reasons[1])
+	tests.Equals(t, "best eng audio", reasons[2])
+	tests.Equals(t, "better codec rank in track 2 of a.mkv", reasons[3])
+	tests.Equals(t, "language mismatch, spa not in eng", reasons[4])
+	tests.Equals(t, "best eng subtitles", reasons[5])
+	tests.Equals(t, "forced limit, better format rank in track 5 of a.mkv", reasons[6])
+	tests.Equals(t, "removed by the -subs filter", reasons[7])
+}
+
+func TestExplainTellsDuplicatesApart(t *testing.T) {
+	ac3 := rankedAudio(0, "A_AC3", "AC-3", 6, "")
+	aac := rankedAudio(1, "A_AAC", "AAC", 2, "")
+
+	tracks := TracksController{
+		Inputs:      explainInputs([]string{"a.mkv"}, map[string]Tracks{"a.mkv": {ac3, aac}}),
+		Preferences: Preferences{Dedupe: true},
+	}
+	tracks.Audios = tracks.Inputs[0].Tracks
+
+	audios, err := tracks.GetBestAudios(nil)
+	tests.Ok(t, err)
+
+	decisions := tracks.Explain(nil, nil, audios, nil)
+	tests.Equals(t, "duplicate, better codec rank in track 1 of a.mkv", decisions[0].Reason)
+	tests.Equals(t, "best of its language and kind", decisions[1].Reason)
+}
+
+func TestExplainTellsWhenNoTrackFitsTheAudioSet(t *testing.T) {
+	surround := rankedAudio(0, "A_AC3", "AC-3", 6, "")
+	stereo := rankedAudio(1, "A_AAC", "AAC", 2, "")
+	stereo.Track.Properties.Language = "spa"
+
+	tracks := TracksController{
+		Inputs:      explainInputs([]string{"a.mkv"}, map[string]Tracks{"a.mkv": {surround, stereo}}),
+		Preferences: Preferences{AudioSet: []string{"stereo"}},
+	}
+	tracks.Audios = tracks.Inputs[0].Tracks
+
+	languages := []string{"eng", "spa"}
+	audios, err := tracks.GetBestAudios(languages)
+	tests.Assert(t, err != nil, "no english stereo track should be found")
+
+	decisions := tracks.Explain(languages, nil, audios, nil)
+	tests.Equals(t, "no eng track fits role stereo", decisions[0].Reason)
+	tests.Equals(t, "best spa audio, but other languages were not found", decisions[1].Reason)
+
+	// Tracks of the language that fit none of the roles
+	stereo.Track.Properties.Language = "eng"
+	audios, err = tracks.GetBestAudios([]string{"eng"})
+	tests.Ok(t, err)
+
+	decisions = tracks.Explain([]string{"eng"}, nil, audios, nil)
+	tests.Equals(t, "does not fit role stereo", decisions[0].Reason)
+	tests.Equals(t, "best eng audio", decisions[1].Reason)
+}
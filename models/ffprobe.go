@@ -0,0 +1,308 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ffprobeStream struct {
+	Index             int               `json:"index"`
+	CodecName         string            `json:"codec_name"`
+	CodecType         string            `json:"codec_type"`
+	Width             int               `json:"width"`
+	Height            int               `json:"height"`
+	SampleAspectRatio string            `json:"sample_aspect_ratio"`
+	FrameRate         string            `json:"r_frame_rate"`
+	Channels          int               `json:"channels"`
+	SampleRate        string            `json:"sample_rate"`
+	Profile           string            `json:"profile"`
+	BitsPerSample     int               `json:"bits_per_sample"`
+	BitsPerRawSample  string            `json:"bits_per_raw_sample"`
+	PixelFormat       string            `json:"pix_fmt"`
+	ColorTransfer     string            `json:"color_transfer"`
+	SideData          []ffprobeSideData `json:"side_data_list"`
+	Disposition       map[string]int    `json:"disposition"`
+	Tags              map[string]string `json:"tags"`
+}
+
+type ffprobeSideData struct {
+	Type string `json:"side_data_type"`
+}
+
+// Transfer characteristics (as defined by ITU-T H.273) for the ffprobe names
+var ffprobeTransfers = map[string]int{
+	"bt709":        1,
+	"smpte2084":    transferPQ,
+	"arib-std-b67": transferHLG,
+}
+
+// Pixel formats bit depth suffix, like yuv420p10le
+var pixelFormatDepth = regexp.MustCompile(`p(\d+)(le|be)$`)
+
+type ffprobeFormat struct {
+	Duration string            `json:"duration"`
+	Tags     map[string]string `json:"tags"`
+}
+
+type ffprobeChapter struct {
+	ID int64 `json:"id"`
+}
+
+type ffprobeOutput struct {
+	Streams  []ffprobeStream  `json:"streams"`
+	Chapters []ffprobeChapter `json:"chapters"`
+	Format   ffprobeFormat    `json:"format"`
+}
+
+/*
+FFprobeProber identifies files using ffprobe, for systems where only ffmpeg
+is available.
+*/
+type FFprobeProber struct{}
+
+/*
+Probe the given file using ffprobe
+*/
+func (FFprobeProber) Probe(file string) (information Info, err error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return
+	}
+
+	output, err := exec.Command(
+		"ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		file,
+	).Output()
+
+	if exit, ok := err.(*exec.ExitError); ok {
+		return information, ffprobeError(exit.Stderr)
+	}
+
+	if err != nil {
+		return
+	}
+
+	if information, err = parseFFprobeJSON(output); err != nil {
+		return
+	}
+
+	information.FileName = file
+	information.FileSize = fi.Size()
+
+	return information, nil
+}
+
+// ffprobeError returns the error reported by ffprobe, telling apart the files
+// it can't recognize from any other failure (like corrupt or unreadable files)
+func ffprobeError(stderr []byte) error {
+	message := strings.TrimSpace(string(stderr))
+	if strings.HasSuffix(message, "Invalid data found when processing input") {
+		return errUnsupportedContainer
+	}
+
+	return fmt.Errorf("ffprobe failed: %s", message)
+}
+
+func parseFFprobeJSON(output []byte) (information Info, err error) {
+	probe := ffprobeOutput{}
+	if err = json.Unmarshal(output, &probe); err != nil {
+		return
+	}
+
+	// ffprobe does not recognize the file
+	if len(probe.Streams) == 0 {
+		return information, errUnsupportedContainer
+	}
+
+	information.Container.Recognized = true
+	information.Container.Supported = true
+
+	properties := &information.Container.Properties
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		properties.Duration = uint64(duration * float64(time.Second))
+	}
+	properties.Title = ffprobeTag(probe.Format.Tags, "title")
+	properties.WritingApplication = ffprobeTag(probe.Format.Tags, "encoder")
+
+	// ffprobe merges all the chapter editions
+	if len(probe.Chapters) > 0 {
+		information.Chapters = []entries{{NumEntries: len(probe.Chapters)}}
+	}
+
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "attachment" {
+			information.Attachments = append(information.Attachments, Attachment{
+				ID:          uint(len(information.Attachments) + 1),
+				FileName:    ffprobeTag(stream.Tags, "filename"),
+				ContentType: ffprobeTag(stream.Tags, "mimetype"),
+			})
+			continue
+		}
+
+		// Attachments, cover arts and data streams are not tracks for mkvmerge,
+		// which numbers the kept ones in order
+		track := &Track{
+			ID:   uint(len(information.Tracks)),
+			Type: ffprobeTrackType(stream.CodecType),
+		}
+		if track.Type == "" || stream.Disposition["attached_pic"] == 1 {
+			continue
+		}
+
+		track.Properties.CodecID = ffprobeCodecs[stream.CodecName]
+		if track.Properties.CodecID == "" {
+			track.Properties.CodecID = strings.ToUpper(stream.CodecName)
+		}
+		track.Codec = ffprobeCodecName(track.Properties.CodecID, stream.Profile)
+
+		track.Properties.Language = stream.Tags["language"]
+		if track.Properties.Language == "" {
+			track.Properties.Language = "und"
+		}
+
+		track.Properties.TrackName = stream.Tags["title"]
+		track.Properties.Default = stream.Disposition["default"] == 1
+		track.Properties.Forced = stream.Disposition["forced"] == 1
+		track.Properties.HearingImpaired = stream.Disposition["hearing_impaired"] == 1
+		track.Properties.VisualImpaired = stream.Disposition["visual_impaired"] == 1
+		track.Properties.Original = stream.Disposition["original"] == 1
+		track.Properties.Commentary = stream.Disposition["comment"] == 1
+
+		// Statistics tags written by mkvmerge are exposed as stream tags
+		track.Properties.TagBps = stream.Tags["BPS"]
+		track.Properties.TagDuration = stream.Tags["DURATION"]
+		track.Properties.TagNumberOfFrames = stream.Tags["NUMBER_OF_FRAMES"]
+		track.Properties.TagNumberOfBytes = stream.Tags["NUMBER_OF_BYTES"]
+
+		switch track.Type {
+		case "video":
+			if stream.Width > 0 && stream.Height > 0 {
+				dimensions := fmt.Sprintf("%dx%d", displayWidth(stream.Width, stream.SampleAspectRatio), stream.Height)
+				track.Properties.Dimensions = &dimensions
+				track.Properties.PixelDimensions = fmt.Sprintf("%dx%d", stream.Width, stream.Height)
+			}
+			track.Properties.DefaultDuration = frameDuration(stream.FrameRate)
+			track.Properties.ColorBitsPerChannel = stream.videoBitDepth()
+			track.Properties.ColorTransferCharacteristics = ffprobeTransfers[stream.ColorTransfer]
+			for _, data := range stream.SideData {
+				if data.Type == "DOVI configuration record" {
+					track.Properties.DolbyVision = true
+				}
+			}
+		case "audio":
+			track.Properties.AudioChannels = stream.Channels
+			track.Properties.AudioSamplingFrequency, _ = strconv.Atoi(stream.SampleRate)
+			track.Properties.AudioBitsPerSample = stream.BitsPerSample
+			if bits, err := strconv.Atoi(stream.BitsPerRawSample); err == nil {
+				track.Properties.AudioBitsPerSample = bits
+			}
+		}
+
+		information.Tracks = append(information.Tracks, TrackController{Track: track})
+	}
+
+	return
+}
+
+// ffprobeCodecName returns the codec name mkvmerge gives to the codec, telling
+// apart the variants ffprobe reports as profiles (like DTS-HD MA)
+func ffprobeCodecName(codecID, profile string) string {
+	switch {
+	case codecID == "A_TRUEHD" && strings.Contains(profile, "Atmos"):
+		return "TrueHD Atmos"
+	case codecID == "A_DTS" && strings.HasPrefix(profile, "DTS-HD MA"):
+		return "DTS-HD Master Audio"
+	case codecID == "A_DTS" && strings.HasPrefix(profile, "DTS-HD HRA"):
+		return "DTS-HD High Resolution Audio"
+	}
+
+	return codecName(codecID)
+}
+
+// videoBitDepth returns the bits per color channel of a video stream, or 0 if
+// unknown
+func (stream ffprobeStream) videoBitDepth() int {
+	if bits, err := strconv.Atoi(stream.BitsPerRawSample); err == nil {
+		return bits
+	}
+
+	if match := pixelFormatDepth.FindStringSubmatch(stream.PixelFormat); match != nil {
+		bits, _ := strconv.Atoi(match[1])
+		return bits
+	}
+
+	// Formats without bit depth suffix (like yuv420p) are 8 bits
+	if stream.PixelFormat != "" {
+		return 8
+	}
+
+	return 0
+}
+
+// ffprobeTag returns the given tag, whose case depends on the container.
+func ffprobeTag(tags map[string]string, name string) string {
+	for key, value := range tags {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func ffprobeTrackType(codecType string) string {
+	switch codecType {
+	case "video", "audio":
+		return codecType
+	case "subtitle":
+		return "subtitles"
+	}
+
+	return ""
+}
+
+// frameDuration returns the duration of each frame (in nanoseconds) for the
+// given frame rate (like 24000/1001).
+func frameDuration(rate string) uint64 {
+	parts := strings.Split(rate, "/")
+	if len(parts) != 2 {
+		return 0
+	}
+
+	num, errn := strconv.ParseUint(parts[0], 10, 64)
+	den, errd := strconv.ParseUint(parts[1], 10, 64)
+	if errn != nil || errd != nil || num == 0 {
+		return 0
+	}
+
+	return uint64(math.Round(float64(den) * 1e9 / float64(num)))
+}
+
+// displayWidth applies the sample aspect ratio (if any) to the given width,
+// the same way mkvmerge reports its display dimensions.
+func displayWidth(width int, sar string) int {
+	parts := strings.Split(sar, ":")
+	if len(parts) != 2 {
+		return width
+	}
+
+	num, errn := strconv.Atoi(parts[0])
+	den, errd := strconv.Atoi(parts[1])
+	if errn != nil || errd != nil || num == 0 || den == 0 || num == den {
+		return width
+	}
+
+	return int(math.Round(float64(width) * float64(num) / float64(den)))
+}
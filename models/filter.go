@@ -0,0 +1,504 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+TrackFilter is a compiled track filter expression, like
+`lang in (spa, eng) and channels >= 6 and codec != "A_DTS"`, whose Match method
+can be given to Tracks.Filter.
+
+Expressions compare the track fields (see filterFields) with values using ==,
+!=, <, <=, >, >=, in (...) and not in (...), and combine them with and, or, not
+and parentheses. Flags (like forced) can be used by themselves.
+*/
+type TrackFilter struct {
+	expression string
+	root       filterNode
+}
+
+/*
+ParseTrackFilter compiles the given filter expression
+*/
+func ParseTrackFilter(expression string) (*TrackFilter, error) {
+	tokens, err := lexFilter(expression)
+	if err != nil {
+		return nil, &FilterSyntaxError{Expression: expression, Err: err}
+	}
+
+	parser := &filterParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err == nil && parser.peek().kind != tokenEnd {
+		err = parser.unexpected()
+	}
+	if err != nil {
+		return nil, &FilterSyntaxError{Expression: expression, Err: err}
+	}
+
+	return &TrackFilter{expression: expression, root: root}, nil
+}
+
+/*
+Match tells whether the track matches the filter
+*/
+func (filter *TrackFilter) Match(track TrackController) bool {
+	return filter.root.match(track)
+}
+
+func (filter *TrackFilter) String() string {
+	return filter.expression
+}
+
+/*
+FilterSyntaxError is returned when a filter expression can't be compiled
+*/
+type FilterSyntaxError struct {
+	Expression string
+	Err        error
+}
+
+func (err *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter %q: %v", err.Expression, err.Err)
+}
+
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	numberField
+	flagField
+)
+
+type filterField struct {
+	kind fieldKind
+	// Compares text fields with the given value
+	equals func(track TrackController, value string) bool
+	number func(track TrackController) float64
+	flag   func(track TrackController) bool
+}
+
+func filterText(value func(track TrackController) string) filterField {
+	return filterField{kind: textField, equals: func(track TrackController, other string) bool {
+		return strings.EqualFold(value(track), other)
+	}}
+}
+
+func filterNumber(value func(track TrackController) float64) filterField {
+	return filterField{kind: numberField, number: value}
+}
+
+func filterFlag(value func(properties properties) bool) filterField {
+	return filterField{kind: flagField, flag: func(track TrackController) bool {
+		return value(track.Track.Properties)
+	}}
+}
+
+// Fields available to the filter expressions
+var filterFields = map[string]filterField{
+	"lang": {kind: textField, equals: func(track TrackController, language string) bool {
+		return track.Track.MatchLanguage(language) > 0
+	}},
+	"codec": {kind: textField, equals: func(track TrackController, codec string) bool {
+		return strings.EqualFold(track.Track.Properties.CodecID, codec) ||
+			strings.EqualFold(track.Track.Codec, codec) ||
+			strings.EqualFold(track.Track.GetAudioCodec(), codec)
+	}},
+	"file": {kind: textField, equals: func(track TrackController, file string) bool {
+		if track.Input == nil {
+			return false
+		}
+
+		return track.Input.FileName == file || filepath.Base(track.Input.FileName) == file
+	}},
+	"name": filterText(func(track TrackController) string { return track.Track.Properties.TrackName }),
+	"type": filterText(func(track TrackController) string { return track.Track.Type }),
+	"hdr":  filterText(func(track TrackController) string { return track.Track.GetHDR() }),
+	"id":   filterNumber(func(track TrackController) float64 { return float64(track.Track.ID) }),
+	// 1-based, as in -set-language
+	"input":    filterNumber(func(track TrackController) float64 { return float64(track.position() + 1) }),
+	"channels": filterNumber(func(track TrackController) float64 { return float64(track.Track.Properties.AudioChannels) }),
+	"bitrate":  filterNumber(func(track TrackController) float64 { return float64(track.Track.GetBitrate()) }),
+	"frames":   filterNumber(func(track TrackController) float64 { return float64(track.Track.GetFrames()) }),
+	"bytes":    filterNumber(func(track TrackController) float64 { return float64(track.Track.GetBytes()) }),
+	"bitdepth": filterNumber(func(track TrackController) float64 { return float64(track.Track.GetBitDepth()) }),
+	"width": filterNumber(func(track TrackController) float64 {
+		width, _ := track.Track.GetResolution()
+		return float64(width)
+	}),
+	"height": filterNumber(func(track TrackController) float64 {
+		_, height := track.Track.GetResolution()
+		return float64(height)
+	}),
+	"forced":           filterFlag(func(p properties) bool { return p.Forced }),
+	"default":          filterFlag(func(p properties) bool { return p.Default }),
+	"original":         filterFlag(func(p properties) bool { return p.Original }),
+	"commentary":       filterFlag(func(p properties) bool { return p.Commentary }),
+	"hearing_impaired": filterFlag(func(p properties) bool { return p.HearingImpaired }),
+	"visual_impaired":  filterFlag(func(p properties) bool { return p.VisualImpaired }),
+}
+
+// Aliases of the filter fields
+var filterAliases = map[string]string{
+	"language": "lang",
+	"sdh":      "hearing_impaired",
+}
+
+func findFilterField(name string) (filterField, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := filterAliases[name]; ok {
+		name = alias
+	}
+	field, ok := filterFields[name]
+
+	return field, ok
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type filterToken struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// keyword tells whether the token is the given (unquoted) keyword
+func (token filterToken) keyword(keyword string) bool {
+	return token.kind == tokenWord && strings.EqualFold(token.text, keyword)
+}
+
+func (token filterToken) String() string {
+	if token.kind == tokenEnd {
+		return "end of expression"
+	}
+
+	return fmt.Sprintf("%q at position %d", token.text, token.position+1)
+}
+
+const filterDelimiters = " \t\n(),'\"=!<>"
+
+func lexFilter(expression string) (tokens []filterToken, err error) {
+	for i := 0; i < len(expression); {
+		char := expression[i]
+		switch {
+		case strings.IndexByte(" \t\n", char) >= 0:
+			i++
+		case char == '(':
+			tokens = append(tokens, filterToken{tokenOpen, "(", i})
+			i++
+		case char == ')':
+			tokens = append(tokens, filterToken{tokenClose, ")", i})
+			i++
+		case char == ',':
+			tokens = append(tokens, filterToken{tokenComma, ",", i})
+			i++
+		case char == '"' || char == '\'':
+			end := strings.IndexByte(expression[i+1:], char)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{tokenString, expression[i+1 : i+1+end], i})
+			i += end + 2
+		case strings.IndexByte("=!<>", char) >= 0:
+			operator := expression[i : i+1]
+			if i+1 < len(expression) && expression[i+1] == '=' {
+				operator = expression[i : i+2]
+			}
+			token := filterToken{tokenOperator, operator, i}
+			i += len(operator)
+
+			switch operator {
+			case "=":
+				token.text = "=="
+			case "!":
+				return nil, fmt.Errorf("unexpected \"!\" at position %d, use not", token.position+1)
+			}
+			tokens = append(tokens, token)
+		default:
+			end := strings.IndexAny(expression[i:], filterDelimiters)
+			if end < 0 {
+				end = len(expression) - i
+			}
+			tokens = append(tokens, filterToken{tokenWord, expression[i : i+end], i})
+			i += end
+		}
+	}
+
+	return append(tokens, filterToken{kind: tokenEnd, position: len(expression)}), nil
+}
+
+type filterParser struct {
+	tokens   []filterToken
+	position int
+}
+
+func (parser *filterParser) peek() filterToken {
+	return parser.tokens[parser.position]
+}
+
+func (parser *filterParser) next() filterToken {
+	token := parser.tokens[parser.position]
+	if token.kind != tokenEnd {
+		parser.position++
+	}
+
+	return token
+}
+
+func (parser *filterParser) unexpected() error {
+	return fmt.Errorf("unexpected %s", parser.peek())
+}
+
+func (parser *filterParser) parseOr() (filterNode, error) {
+	left, err := parser.parseAnd()
+	for err == nil && parser.peek().keyword("or") {
+		parser.next()
+
+		var right filterNode
+		if right, err = parser.parseAnd(); err == nil {
+			left = orNode{left, right}
+		}
+	}
+
+	return left, err
+}
+
+func (parser *filterParser) parseAnd() (filterNode, error) {
+	left, err := parser.parseUnary()
+	for err == nil && parser.peek().keyword("and") {
+		parser.next()
+
+		var right filterNode
+		if right, err = parser.parseUnary(); err == nil {
+			left = andNode{left, right}
+		}
+	}
+
+	return left, err
+}
+
+func (parser *filterParser) parseUnary() (filterNode, error) {
+	token := parser.peek()
+
+	if token.keyword("not") {
+		parser.next()
+		node, err := parser.parseUnary()
+
+		return notNode{node}, err
+	}
+
+	if token.kind == tokenOpen {
+		parser.next()
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parser.peek().kind != tokenClose {
+			return nil, parser.unexpected()
+		}
+		parser.next()
+
+		return node, nil
+	}
+
+	return parser.parseComparison()
+}
+
+func (parser *filterParser) parseComparison() (filterNode, error) {
+	token := parser.peek()
+	if token.kind != tokenWord {
+		return nil, parser.unexpected()
+	}
+	parser.next()
+
+	field, ok := findFilterField(token.text)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d", token.text, token.position+1)
+	}
+	node := comparisonNode{name: token.text, field: field}
+
+	switch next := parser.peek(); {
+	case next.kind == tokenOperator:
+		node.operator = parser.next().text
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.values = []string{value}
+	case next.keyword("in"):
+		parser.next()
+		node.operator = "in"
+	case next.keyword("not") && parser.tokens[parser.position+1].keyword("in"):
+		parser.position += 2
+		node.operator = "not in"
+	default:
+		// Flags can be used by themselves
+		if field.kind != flagField {
+			return nil, fmt.Errorf("%s is not a flag, it must be compared with a value", token.text)
+		}
+		node.operator, node.values = "==", []string{"true"}
+	}
+
+	if node.operator == "in" || node.operator == "not in" {
+		values, err := parser.parseList()
+		if err != nil {
+			return nil, err
+		}
+		node.values = values
+	}
+
+	return node, node.compile()
+}
+
+func (parser *filterParser) parseValue() (string, error) {
+	token := parser.peek()
+	if token.kind != tokenWord && token.kind != tokenString {
+		return "", parser.unexpected()
+	}
+	parser.next()
+
+	return token.text, nil
+}
+
+func (parser *filterParser) parseList() (values []string, err error) {
+	if parser.peek().kind != tokenOpen {
+		return nil, parser.unexpected()
+	}
+	parser.next()
+
+	for {
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch parser.peek().kind {
+		case tokenComma:
+			parser.next()
+		case tokenClose:
+			parser.next()
+			return values, nil
+		default:
+			return nil, parser.unexpected()
+		}
+	}
+}
+
+type filterNode interface {
+	match(track TrackController) bool
+}
+
+type andNode struct {
+	left, right filterNode
+}
+
+func (node andNode) match(track TrackController) bool {
+	return node.left.match(track) && node.right.match(track)
+}
+
+type orNode struct {
+	left, right filterNode
+}
+
+func (node orNode) match(track TrackController) bool {
+	return node.left.match(track) || node.right.match(track)
+}
+
+type notNode struct {
+	node filterNode
+}
+
+func (node notNode) match(track TrackController) bool {
+	return !node.node.match(track)
+}
+
+type comparisonNode struct {
+	name     string
+	field    filterField
+	operator string
+	values   []string
+	numbers  []float64
+	flag     bool
+}
+
+// compile checks the operator and values are valid for the field
+func (node *comparisonNode) compile() error {
+	ordering := strings.ContainsAny(node.operator, "<>")
+
+	switch node.field.kind {
+	case textField:
+		if ordering {
+			return fmt.Errorf("%s can't be compared with %s", node.name, node.operator)
+		}
+	case numberField:
+		for _, value := range node.values {
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be compared with numbers, not %q", node.name, value)
+			}
+			node.numbers = append(node.numbers, number)
+		}
+	case flagField:
+		value, err := strconv.ParseBool(node.values[0])
+		if ordering || len(node.values) > 1 || err != nil {
+			return fmt.Errorf("%s is a flag, it can only be compared with true or false", node.name)
+		}
+		node.flag = value
+	}
+
+	return nil
+}
+
+func (node comparisonNode) match(track TrackController) bool {
+	switch node.field.kind {
+	case flagField:
+		return (node.field.flag(track) == node.flag) == (node.operator == "==" || node.operator == "in")
+	case numberField:
+		return node.compare(node.field.number(track))
+	}
+
+	equals := false
+	for _, value := range node.values {
+		if node.field.equals(track, value) {
+			equals = true
+		}
+	}
+
+	return equals == (node.operator == "==" || node.operator == "in")
+}
+
+func (node comparisonNode) compare(value float64) bool {
+	switch node.operator {
+	case "<":
+		return value < node.numbers[0]
+	case "<=":
+		return value <= node.numbers[0]
+	case ">":
+		return value > node.numbers[0]
+	case ">=":
+		return value >= node.numbers[0]
+	}
+
+	equals := false
+	for _, number := range node.numbers {
+		if value == number {
+			equals = true
+		}
+	}
+
+	return equals == (node.operator == "==" || node.operator == "in")
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func filterTracks() Tracks {
+	return Tracks{
+		rankedAudio(0, "A_DTS", "DTS", 6, "1536000"),
+		rankedAudio(1, "A_AC3", "AC-3", 6, "640000"),
+		rankedAudio(2, "A_AAC", "AAC", 2, "128000"),
+		{
+			Input: &Info{FileName: "/movies/dub.mkv", Position: 1},
+			Track: &Track{ID: 3, Type: "audio", Codec: "AC-3", Properties: properties{
+				CodecID:       "A_AC3",
+				Language:      "spa",
+				LanguageIETF:  "es-ES",
+				AudioChannels: 6,
+				Commentary:    true,
+				TrackName:     "Comentarios del director",
+			}},
+		},
+	}
+}
+
+func filteredIDs(t *testing.T, expression string) (ids []uint) {
+	filter, err := ParseTrackFilter(expression)
+	tests.Ok(t, err)
+
+	for _, track := range filterTracks().Filter(filter.Match) {
+		ids = append(ids, track.Track.ID)
+	}
+
+	return
+}
+
+func TestTrackFilterExpressions(t *testing.T) {
+	cases := map[string][]uint{
+		`lang in (spa, eng) and channels >= 6 and codec != "A_DTS"`: {1, 3},
+		`lang == es and not commentary`:                             nil,
+		`lang = es-ES and commentary`:                               {3},
+		`codec in (dts, 'AC-3') or bitrate < 200000`:                {0, 1, 2, 3},
+		`not (channels > 2 or lang == spa)`:                         {2},
+		`codec not in (A_AAC, A_DTS) and input == 1`:                {1},
+		`file == dub.mkv or id <= 0`:                                {0, 3},
+		`name == "comentarios del director" and commentary == true`: {3},
+		`lang == eng and ((codec == aac))`:                          {2},
+		`commentary in (true)`:                                      {3},
+		`commentary not in (true)`:                                  {0, 1, 2},
+	}
+
+	for expression, expected := range cases {
+		tests.Equals(t, expected, filteredIDs(t, expression))
+	}
+}
+
+func TestParseTrackFilterReportsSyntaxErrors(t *testing.T) {
+	cases := map[string]string{
+		`lang in (spa, eng`:       `unexpected end of expression`,
+		`lang == spa and`:         `unexpected end of expression`,
+		`resolution > 720`:        `unknown field "resolution" at position 1`,
+		`channels >= six`:         `channels must be compared with numbers, not "six"`,
+		`lang > spa`:              `lang can't be compared with >`,
+		`forced == maybe`:         `forced is a flag, it can only be compared with true or false`,
+		`lang`:                    `lang is not a flag, it must be compared with a value`,
+		`name == 'unterminated`:   `unterminated string at position 9`,
+		`lang == spa ) or forced`: `unexpected ")" at position 13`,
+		`!forced`:                 `unexpected "!" at position 1, use not`,
+	}
+
+	for expression, message := range cases {
+		_, err := ParseTrackFilter(expression)
+		tests.Assert(t, err != nil, "%s must fail", expression)
+		tests.Equals(t, fmt.Sprintf("invalid filter %q: %s", expression, message), err.Error())
+	}
+}
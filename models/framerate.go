@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+/*
+FrameRate is a video frame rate, as a fraction (like 24000/1001)
+*/
+type FrameRate struct {
+	Numerator   int64
+	Denominator int64
+}
+
+// Frame rates the tracks default durations are snapped to
+var knownFrameRates = []FrameRate{
+	{24000, 1001},
+	{24, 1},
+	{25, 1},
+	{30000, 1001},
+	{30, 1},
+	{48, 1},
+	{50, 1},
+	{60000, 1001},
+	{60, 1},
+}
+
+const (
+	// Maximum relative difference to snap a frame rate to a known one
+	frameRateTolerance = 0.0003
+	// Maximum relative difference between the inputs durations ratio and the
+	// ratio between two known frame rates
+	frameRateRatioTolerance = 0.002
+	// Minimum durations ratio considered a frame rate change (so 1.001 changes
+	// can't be detected from durations, as they're too close to different cuts)
+	minFrameRateRatio = 0.01
+)
+
+func (rate FrameRate) float() float64 {
+	return float64(rate.Numerator) / float64(rate.Denominator)
+}
+
+func (rate FrameRate) String() string {
+	return fmt.Sprintf("%.3f fps", rate.float())
+}
+
+/*
+GetFrameRate returns the frame rate of a video track, based on its default
+duration and snapped to the usual ones
+*/
+func (track *Track) GetFrameRate() *FrameRate {
+	if track.Type != "video" || track.Properties.DefaultDuration == 0 {
+		return nil
+	}
+
+	fps := 1e9 / float64(track.Properties.DefaultDuration)
+	for _, rate := range knownFrameRates {
+		if math.Abs(fps-rate.float())/rate.float() < frameRateTolerance {
+			rate := rate
+			return &rate
+		}
+	}
+
+	return nil
+}
+
+/*
+GetFactor returns the fraction the timestamps of the input are multiplied by
+to match the video frame rate (1/1 if they already match)
+*/
+func (sync *Sync) GetFactor() (numerator, denominator int64) {
+	if sync == nil || sync.FrameRate == nil || sync.VideoFrameRate == nil {
+		return 1, 1
+	}
+
+	// Not reduced, so they're the usual ones (like 25025/24000)
+	numerator = sync.FrameRate.Numerator * sync.VideoFrameRate.Denominator
+	denominator = sync.FrameRate.Denominator * sync.VideoFrameRate.Numerator
+
+	return
+}
+
+func (sync *Sync) factor() float64 {
+	numerator, denominator := sync.GetFactor()
+
+	return float64(numerator) / float64(denominator)
+}
+
+/*
+DetectFrameRates compares the frame rate of the inputs of the given tracks
+with the video one, setting the input FrameRate (in its Sync) when they differ,
+like PAL releases sped up from 23.976 to 25 fps. Inputs without video are
+compared by their duration, which is only reliable for PAL speed-ups.
+*/
+func DetectFrameRates(video *TrackController, selections ...Tracks) {
+	videoRate := video.Track.GetFrameRate()
+	if videoRate == nil {
+		return
+	}
+
+	checked := map[*Info]bool{video.Input: true}
+	for _, tracks := range selections {
+		for _, track := range tracks {
+			if checked[track.Input] || track.Input == nil {
+				continue
+			}
+			checked[track.Input] = true
+
+			rate := inputFrameRate(track.Input)
+			if rate == nil && !hasTimedTracks(track.Input) {
+				continue
+			}
+			if rate == nil {
+				rate = durationFrameRate(video.Input, track.Input, *videoRate)
+			}
+			if rate == nil || *rate == *videoRate {
+				continue
+			}
+
+			if track.Input.Sync == nil {
+				track.Input.Sync = &Sync{}
+			}
+			track.Input.Sync.FrameRate = rate
+			track.Input.Sync.VideoFrameRate = videoRate
+		}
+	}
+}
+
+func inputFrameRate(input *Info) *FrameRate {
+	for _, track := range input.Tracks {
+		if rate := track.Track.GetFrameRate(); rate != nil {
+			return rate
+		}
+	}
+
+	return nil
+}
+
+// durationFrameRate guesses the frame rate of an input without video from
+// the ratio between its duration and the video one
+func durationFrameRate(video, input *Info, videoRate FrameRate) *FrameRate {
+	videoDuration := inputDuration(video)
+	duration := inputDuration(input)
+	if videoDuration == 0 || duration == 0 {
+		return nil
+	}
+
+	// Faster frame rates make shorter inputs
+	ratio := float64(videoDuration) / float64(duration)
+	if math.Abs(ratio-1) < minFrameRateRatio {
+		return nil
+	}
+
+	for _, rate := range knownFrameRates {
+		expected := rate.float() / videoRate.float()
+		if math.Abs(ratio-expected)/expected < frameRateRatioTolerance {
+			rate := rate
+			return &rate
+		}
+	}
+
+	return nil
+}
+
+// stretch returns the duration once multiplied by the input factor
+func (sync *Sync) stretch(duration time.Duration) time.Duration {
+	numerator, denominator := sync.GetFactor()
+
+	return time.Duration(int64(duration) / denominator * numerator)
+}
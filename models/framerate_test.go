@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func frameRateInput(name string, seconds uint64, defaultDuration uint64, types ...string) *Info {
+	input := durationInput(name, seconds, types...)
+	for i := range input.Tracks {
+		input.Tracks[i].Input = input
+		if input.Tracks[i].Track.Type == "video" {
+			input.Tracks[i].Track.Properties.DefaultDuration = defaultDuration
+		}
+	}
+
+	return input
+}
+
+func TestGetFrameRateSnapsToKnownRates(t *testing.T) {
+	cases := map[uint64]*FrameRate{
+		41708333: {24000, 1001},
+		41666667: {24, 1},
+		40000000: {25, 1},
+		33366667: {30000, 1001},
+		16683333: {60000, 1001},
+		45000000: nil,
+		0:        nil,
+	}
+
+	for duration, expected := range cases {
+		track := &Track{Type: "video", Properties: properties{DefaultDuration: duration}}
+		tests.Equals(t, expected, track.GetFrameRate())
+	}
+}
+
+func TestDetectFrameRatesFindsPALInputs(t *testing.T) {
+	video := frameRateInput("bluray.mkv", 7200, 41708333, "video", "audio")
+	pal := frameRateInput("dvd.mkv", 6905, 40000000, "video", "audio")
+	dub := frameRateInput("dub.mka", 6905, 0, "audio")
+	cut := frameRateInput("cut.mka", 7150, 0, "audio")
+	same := frameRateInput("web.mkv", 7200, 41708333, "video", "audio")
+
+	DetectFrameRates(&video.Tracks[0], Tracks{pal.Tracks[1], dub.Tracks[0], cut.Tracks[0], same.Tracks[1]})
+
+	tests.Equals(t, &Sync{FrameRate: &FrameRate{25, 1}, VideoFrameRate: &FrameRate{24000, 1001}}, pal.Sync)
+	tests.Equals(t, &Sync{FrameRate: &FrameRate{25, 1}, VideoFrameRate: &FrameRate{24000, 1001}}, dub.Sync)
+	tests.Assert(t, cut.Sync == nil, "different cuts are not frame rate changes")
+	tests.Assert(t, same.Sync == nil, "same frame rates need no correction")
+
+	numerator, denominator := pal.Sync.GetFactor()
+	tests.Equals(t, []int64{25025, 24000}, []int64{numerator, denominator})
+
+	// 6905s stretched by 25025/24000 last 7200s
+	tests.Ok(t, CheckDurations(&video.Tracks[0], 2*time.Second, Tracks{pal.Tracks[1], dub.Tracks[0]}))
+}
+
+func TestStretchEnvelope(t *testing.T) {
+	tests.Equals(t, []float64{0, 0.5, 1, 1}, stretchEnvelope([]float64{0, 1}, 2))
+	tests.Equals(t, []float64{1, 2}, stretchEnvelope([]float64{1, 2}, 1))
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Sources of the inferred languages
+const (
+	InferenceTrackName = "track name"
+	InferenceFileName  = "file name"
+	InferenceText      = "text"
+)
+
+const (
+	// Minimum confidence required to use a language inferred from the text
+	minTextConfidence = 0.15
+	// Bytes of subtitle text used to classify its language
+	inferenceTextLimit = 16 * 1024
+)
+
+// Codec IDs of the subtitles whose text can be read
+var textSubtitleCodecs = map[string]bool{
+	"S_TEXT/UTF8":   true,
+	"S_TEXT/ASCII":  true,
+	"S_TEXT/ASS":    true,
+	"S_TEXT/SSA":    true,
+	"S_TEXT/WEBVTT": true,
+	"S_ASS":         true,
+	"S_SSA":         true,
+}
+
+var (
+	subtitleTagsRegexp   = regexp.MustCompile(`\{[^}]*\}|<[^>]*>`)
+	subtitleTimingRegexp = regexp.MustCompile(`^\d+$|-->|^WEBVTT|^NOTE`)
+)
+
+/*
+Inference is the language guessed for a track without language
+*/
+type Inference struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+/*
+InferLanguages guesses the language of the audio and subtitle tracks tagged as
+"und" (or without language) from their track name, their file name and, for
+text subtitles, their text. The inferred languages are set to the tracks, so
+they're used by the selection functions and set to the output.
+*/
+func (controller TracksController) InferLanguages() {
+	for _, input := range controller.Inputs {
+		for _, track := range input.Tracks {
+			if track.Track.Type == "video" || !track.Track.HasUndefinedLanguage() {
+				continue
+			}
+
+			inference := track.inferLanguage()
+			if inference == nil {
+				continue
+			}
+
+			track.Track.Inference = inference
+			track.Track.Properties.Language = inference.Language
+		}
+	}
+}
+
+/*
+HasUndefinedLanguage checks whether the track language is unknown
+*/
+func (track *Track) HasUndefinedLanguage() bool {
+	return track.Properties.Language == "" || track.Properties.Language == "und"
+}
+
+func (track TrackController) inferLanguage() *Inference {
+	if language := languageFromWords(track.Track.Properties.TrackName); language != "" {
+		return &Inference{Language: language, Confidence: 0.9, Source: InferenceTrackName}
+	}
+
+	if track.Input != nil {
+		name := filepath.Base(track.Input.FileName)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+		if language := languageFromWords(name); language != "" {
+			return &Inference{Language: language, Confidence: 0.6, Source: InferenceFileName}
+		}
+	}
+
+	if track.Track.Type != "subtitles" || !textSubtitleCodecs[track.Track.Properties.CodecID] {
+		return nil
+	}
+
+	language, confidence := classifyText(track.subtitleText())
+	if language == "" || confidence < minTextConfidence {
+		return nil
+	}
+
+	return &Inference{Language: language, Confidence: confidence, Source: InferenceText}
+}
+
+// languageFromWords returns the language found in the given text (like
+// "English SDH" or "Movie.2019.SPA.1080p"), if only one is found. Words
+// shorter than three letters are ignored, as most of them are not languages,
+// and codes must be uppercased (so "Ben" is not taken as Bengali). Lowercased
+// names are only taken when joined to other words by dots, dashes or brackets
+// (like "movie.english"), so titles like "the english patient" are skipped.
+// The whole text is just checked against the languages names, which may have
+// more than one word (like "tiếng việt").
+func languageFromWords(text string) (language string) {
+	if match, byName := findLanguage(text); match != nil && byName {
+		return match.Bibliographic
+	}
+
+	for _, field := range strings.Fields(text) {
+		words := strings.FieldsFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+
+		for _, word := range words {
+			if len([]rune(word)) < 3 {
+				continue
+			}
+
+			match, byName := findLanguage(word)
+			if match == nil || (!byName && word != strings.ToUpper(word)) {
+				continue
+			}
+			if byName && word == strings.ToLower(word) && word == field {
+				continue
+			}
+
+			found := match.Bibliographic
+			// Ambiguous, like "English / Spanish"
+			if language != "" && found != language {
+				return ""
+			}
+			language = found
+		}
+	}
+
+	return
+}
+
+// subtitleText reads the text of external subtitle files or of the Matroska
+// text subtitle tracks, without their timings and styles.
+func (track TrackController) subtitleText() string {
+	if track.Input == nil {
+		return ""
+	}
+
+	file := track.Input.FileName
+	ass := strings.Contains(track.Track.Properties.CodecID, "SSA") ||
+		strings.Contains(track.Track.Properties.CodecID, "ASS")
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".srt", ".ass", ".ssa", ".vtt":
+		f, err := os.Open(file)
+		if err != nil {
+			return ""
+		}
+		defer f.Close()
+
+		data, err := ioutil.ReadAll(io.LimitReader(f, inferenceTextLimit))
+		if err != nil {
+			return ""
+		}
+
+		return subtitleFileText(string(data), ass)
+	case ".mkv", ".mka", ".mks", ".mk3d", ".webm":
+		if track.Track.Properties.Number == 0 {
+			return ""
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return ""
+		}
+		defer f.Close()
+
+		blocks, err := readMatroskaText(f, uint64(track.Track.Properties.Number), inferenceTextLimit)
+		if err != nil && len(blocks) == 0 {
+			return ""
+		}
+
+		var lines []string
+		for _, block := range blocks {
+			if ass {
+				// ReadOrder, Layer, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+				block = nthField(block, 8)
+			}
+			lines = append(lines, cleanSubtitleLine(block))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	return ""
+}
+
+// subtitleFileText extracts the dialogues of SubRip, WebVTT and SSA/ASS files
+func subtitleFileText(data string, ass bool) string {
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+
+		if ass {
+			if !strings.HasPrefix(line, "Dialogue:") {
+				continue
+			}
+			// Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+			line = nthField(line, 9)
+		} else if line == "" || subtitleTimingRegexp.MatchString(line) {
+			continue
+		}
+
+		lines = append(lines, cleanSubtitleLine(line))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func cleanSubtitleLine(line string) string {
+	line = strings.NewReplacer(`\N`, " ", `\n`, " ", `\h`, " ").Replace(line)
+
+	return strings.TrimSpace(subtitleTagsRegexp.ReplaceAllString(line, ""))
+}
+
+// nthField returns the text after the nth comma
+func nthField(line string, n int) string {
+	parts := strings.SplitN(line, ",", n+1)
+	if len(parts) <= n {
+		return ""
+	}
+
+	return parts[n]
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+const spanishDialogue = `1
+00:00:01,000 --> 00:00:03,000
+<i>¿Dónde has estado toda la noche?</i>
+
+2
+00:00:04,000 --> 00:00:06,000
+Te he estado buscando por todas partes.
+
+3
+00:00:07,000 --> 00:00:09,000
+Lo siento, tenía que ayudar a mi hermano
+con el coche, que se había quedado sin gasolina.
+`
+
+func undefinedTrack(file, kind, codec, name string) TrackController {
+	input := &Info{FileName: file}
+	track := TrackController{Input: input, Track: &Track{Type: kind}}
+	track.Track.Properties.Language = "und"
+	track.Track.Properties.CodecID = codec
+	track.Track.Properties.TrackName = name
+	input.Tracks = Tracks{track}
+
+	return track
+}
+
+func TestInferLanguagesFromTrackAndFileNames(t *testing.T) {
+	byName := undefinedTrack("movie.mkv", "audio", "A_AC3", "Castellano 5.1")
+	byFile := undefinedTrack("Movie.2019.ENG.1080p.mkv", "audio", "A_AC3", "")
+	ambiguous := undefinedTrack("Movie.DUAL.SPA-ENG.mkv", "audio", "A_AC3", "Surround")
+	video := undefinedTrack("Movie.ENG.mkv", "video", "V_MPEG4/ISO/AVC", "")
+
+	controller := TracksController{Inputs: []*Info{byName.Input, byFile.Input, ambiguous.Input, video.Input}}
+	controller.InferLanguages()
+
+	tests.Equals(t, "spa", byName.Track.Properties.Language)
+	tests.Equals(t, &Inference{Language: "spa", Confidence: 0.9, Source: InferenceTrackName}, byName.Track.Inference)
+	tests.Equals(t, "eng", byFile.Track.Properties.Language)
+	tests.Equals(t, InferenceFileName, byFile.Track.Inference.Source)
+	tests.Equals(t, "und", ambiguous.Track.Properties.Language)
+	tests.Equals(t, "und", video.Track.Properties.Language)
+}
+
+func TestLanguageFromWords(t *testing.T) {
+	cases := map[string]string{
+		"English SDH":                "eng",
+		"Movie.2019.SPA.1080p":       "spa",
+		"movie.2019.castellano.720p": "spa",
+		"Commentary [english]":       "eng",
+		"the english patient":        "",
+		"Ben and the spanish guitar": "",
+		"English / Spanish":          "",
+		"Tiếng Việt":                 "vie",
+		"HI":                         "",
+		"no":                         "",
+		"ben":                        "",
+		"it":                         "",
+	}
+
+	for text, expected := range cases {
+		tests.Equals(t, expected, languageFromWords(text))
+	}
+}
+
+func TestInferLanguagesFromSubtitlesText(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remuxing")
+	tests.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "movie.srt")
+	tests.Ok(t, ioutil.WriteFile(file, []byte(spanishDialogue), 0644))
+
+	track := undefinedTrack(file, "subtitles", "S_TEXT/UTF8", "")
+	TracksController{Inputs: []*Info{track.Input}}.InferLanguages()
+
+	tests.Equals(t, "spa", track.Track.Properties.Language)
+	tests.Equals(t, InferenceText, track.Track.Inference.Source)
+	tests.Assert(t, track.Track.Inference.Confidence >= minTextConfidence, "unexpected confidence %f", track.Track.Inference.Confidence)
+}
+
+func TestClassifyText(t *testing.T) {
+	cases := map[string]string{
+		"eng": "I told you not to go there. Why didn't you listen to me? Now we have a big problem and nobody is going to help us, not even your friends.",
+		"fre": "Je t'avais dit de ne pas y aller. Pourquoi tu ne m'as pas écouté ? Maintenant on a un gros problème et personne ne va nous aider, même pas tes amis.",
+		"ger": "Ich habe dir gesagt, du sollst da nicht hingehen. Warum hast du nicht auf mich gehört? Jetzt haben wir ein großes Problem und niemand wird uns helfen.",
+		"ita": "Ti avevo detto di non andare lì. Perché non mi hai ascoltato? Adesso abbiamo un grosso problema e nessuno ci aiuterà, nemmeno i tuoi amici.",
+		"":    "Too short",
+	}
+
+	for expected, text := range cases {
+		language, _ := classifyText(text)
+		tests.Equals(t, expected, language)
+	}
+}
+
+func TestSubtitleFileTextExtractsDialogues(t *testing.T) {
+	ass := "[Script Info]\nTitle: test\n\n[Events]\n" +
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\i1}Hello,\\Nthere{\\i0}\n"
+
+	tests.Equals(t, "Hello, there", subtitleFileText(ass, true))
+	tests.Equals(t, "¿Dónde has estado toda la noche?", subtitleFileText("1\n00:00:01,000 --> 00:00:03,000\n<i>¿Dónde has estado toda la noche?</i>\n", false))
+}
+
+func TestReadMatroskaTextDecompressesTheTrackBlocks(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte("Hola, ¿qué tal?"))
+	writer.Close()
+
+	block := func(number byte, data []byte) []byte {
+		return append([]byte{0x80 | number, 0, 0, 0}, data...)
+	}
+
+	mkv := append(
+		ebml(mkvEBML, ebmlString(mkvDocType, "matroska")),
+		ebml(mkvSegment,
+			ebml(mkvTracks,
+				ebml(mkvTrackEntry,
+					ebmlUint(mkvTrackNumber, 1),
+					ebmlUint(mkvTrackType, mkvTrackTypeVideo),
+				),
+				ebml(mkvTrackEntry,
+					ebmlUint(mkvTrackNumber, 2),
+					ebmlUint(mkvTrackType, mkvTrackTypeSubtitles),
+					ebmlString(mkvCodecID, "S_TEXT/UTF8"),
+					ebml(mkvContentEncs, ebml(mkvContentEnc, ebml(mkvContentComp))),
+				),
+			),
+			ebml(mkvCluster,
+				ebml(mkvSimpleBlock, block(1, bytes.Repeat([]byte{0xFF}, 32))),
+				ebml(mkvBlockGroup, ebml(mkvBlock, block(2, compressed.Bytes()))),
+			),
+			ebml(mkvCluster,
+				ebml(mkvBlockGroup, ebml(mkvBlock, block(2, compressed.Bytes()))),
+			),
+		)...,
+	)
+
+	blocks, err := readMatroskaText(bytes.NewReader(mkv), 2, 1024)
+
+	tests.Ok(t, err)
+	tests.Equals(t, []string{"Hola, ¿qué tal?", "Hola, ¿qué tal?"}, blocks)
+
+	clusters := matroskaTextClusters
+	defer func() { matroskaTextClusters = clusters }()
+	matroskaTextClusters = 1
+
+	blocks, err = readMatroskaText(bytes.NewReader(mkv), 2, 1024)
+
+	tests.Ok(t, err)
+	tests.Equals(t, []string{"Hola, ¿qué tal?"}, blocks)
+}
+
+func TestReadMatroskaBlockLimitsTheDecompressedSize(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(bytes.Repeat([]byte("a"), 10*matroskaTextBlockLimit))
+	writer.Close()
+
+	block := append([]byte{0x81, 0, 0, 0}, compressed.Bytes()...)
+	track := &matroskaTrack{Number: 1, Compression: mkvCompressionZlib}
+
+	text, err := readMatroskaBlock(bytes.NewReader(block), int64(len(block)), track)
+
+	tests.Ok(t, err)
+	tests.Equals(t, matroskaTextBlockLimit, len(text))
+}
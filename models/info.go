@@ -5,16 +5,47 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 type props struct {
-	Duration uint64
+	// In nanoseconds
+	Duration           uint64
+	Title              string `json:"title,omitempty"`
+	MuxingApplication  string `json:"muxing_application,omitempty"`
+	WritingApplication string `json:"writing_application,omitempty"`
+	SegmentUID         string `json:"segment_uid,omitempty"`
+	DateUTC            string `json:"date_utc,omitempty"`
+}
+
+type entries struct {
+	NumEntries int `json:"num_entries"`
+}
+
+type attachmentProps struct {
+	UID uint64 `json:"uid,omitempty"`
+}
+
+/*
+Attachment is a file attached to a container (like fonts or cover images)
+*/
+type Attachment struct {
+	ID          uint            `json:"id"`
+	FileName    string          `json:"file_name"`
+	ContentType string          `json:"content_type"`
+	Description string          `json:"description,omitempty"`
+	Size        int64           `json:"size"`
+	Properties  attachmentProps `json:"properties"`
 }
 
 type container struct {
 	Properties props
+	Recognized bool
 	Supported  bool
 }
 
@@ -22,11 +53,16 @@ type container struct {
 Info is the main video information object/struct
 */
 type Info struct {
-	Container container
-	Tracks    Tracks `json:"tracks"`
-	FileName  string `json:"file_name"`
-	Position  int
-	FileSize  int64
+	Container   container
+	Tracks      Tracks       `json:"tracks"`
+	Chapters    []entries    `json:"chapters"`
+	Attachments []Attachment `json:"attachments"`
+	GlobalTags  []entries    `json:"global_tags"`
+	FileName    string       `json:"file_name"`
+	Position    int
+	FileSize    int64
+	// Offset to sync its tracks with the video, set by SyncDetector
+	Sync *Sync `json:"-"`
 }
 
 /*
@@ -38,10 +74,71 @@ func (information *Info) SetPosition(position int) int {
 	return information.Position
 }
 
+/*
+GetDuration returns the container duration, as reported in nanoseconds
+*/
+func (information *Info) GetDuration() time.Duration {
+	return time.Duration(information.Container.Properties.Duration)
+}
+
+/*
+GetChapterEditions returns the number of chapter editions in the container
+*/
+func (information *Info) GetChapterEditions() int {
+	return len(information.Chapters)
+}
+
+/*
+GetGlobalTags returns the number of global tags in the container
+*/
+func (information *Info) GetGlobalTags() (count int) {
+	for _, tags := range information.GlobalTags {
+		count += tags.NumEntries
+	}
+
+	return
+}
+
+/*
+GetFonts returns the attachments which are fonts, usually required by
+SubStationAlpha subtitles
+*/
+func (information *Info) GetFonts() (fonts []Attachment) {
+	for _, attachment := range information.Attachments {
+		if attachment.IsFont() {
+			fonts = append(fonts, attachment)
+		}
+	}
+
+	return
+}
+
+/*
+IsFont checks whether the attachment is a font, by its MIME type or extension
+*/
+func (attachment Attachment) IsFont() bool {
+	mime := strings.ToLower(attachment.ContentType)
+	if strings.Contains(mime, "font") || strings.Contains(mime, "opentype") {
+		return true
+	}
+
+	switch strings.ToLower(filepath.Ext(attachment.FileName)) {
+	case ".ttf", ".otf", ".ttc":
+		return true
+	}
+
+	return false
+}
+
 /*
 GetFileInfo does.. well, that :_)
 */
 func GetFileInfo(file string) (information Info, err error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return
+	}
+
 	output, err := exec.Command(
 		"mkvmerge",
 		"-F",
@@ -50,23 +147,68 @@ func GetFileInfo(file string) (information Info, err error) {
 		file,
 	).CombinedOutput()
 
+	// mkvmerge reports identification errors as part of its JSON output
+	if errs := mkvmergeErrors(output); errs != nil {
+		return information, errs
+	}
+
 	if err != nil {
 		return
 	}
 
-	if err = json.Unmarshal(output, &information); err != nil {
+	if information, err = parseMkvmergeJSON(output); err != nil {
 		return
 	}
 
-	fi, err := os.Stat(file)
-	if err != nil {
+	information.FileSize = fi.Size()
+
+	return information, nil
+}
+
+func parseMkvmergeJSON(output []byte) (information Info, err error) {
+	if err = json.Unmarshal(output, &information); err != nil {
 		return
 	}
 
-	information.FileSize = fi.Size()
+	if !information.Container.Supported {
+		return information, errUnsupportedContainer
+	}
+
+	return
+}
 
-	// We want it in seconds, so we don't care about decimals
-	information.Container.Properties.Duration = information.Container.Properties.Duration / 1000 / 1000 / 1000
+func mkvmergeErrors(output []byte) error {
+	identification := struct {
+		Container container `json:"container"`
+		Errors    []string  `json:"errors"`
+	}{}
 
-	return information, nil
+	if json.Unmarshal(output, &identification) != nil {
+		return nil
+	}
+
+	// Unrecognized files are reported as errors too
+	if !identification.Container.Recognized {
+		return errUnsupportedContainer
+	}
+
+	if len(identification.Errors) > 0 {
+		return errors.New(strings.Join(identification.Errors, " "))
+	}
+
+	return nil
+}
+
+func (information Info) clone() Info {
+	tracks := make(Tracks, len(information.Tracks))
+	for i, track := range information.Tracks {
+		copied := *track.Track
+		tracks[i] = TrackController{Track: &copied}
+	}
+	information.Tracks = tracks
+	information.Chapters = append([]entries(nil), information.Chapters...)
+	information.Attachments = append([]Attachment(nil), information.Attachments...)
+	information.GlobalTags = append([]entries(nil), information.GlobalTags...)
+
+	return information
 }
@@ -0,0 +1,58 @@
+package models
+
+import "regexp"
+
+/*
+Track kinds, as returned by Track.Kind
+*/
+const (
+	KindMain        = "main"
+	KindCommentary  = "commentary"
+	KindDescription = "description"
+	KindSDH         = "sdh"
+	KindSigns       = "signs"
+)
+
+/*
+OptionalKinds are the track kinds that are only taken when asked to (see
+Preferences.IncludeKinds)
+*/
+var OptionalKinds = []string{KindCommentary, KindDescription, KindSDH, KindSigns}
+
+// Track names of each kind. Abbreviations are case sensitive, as they're
+// usual words in lowercase.
+var (
+	commentaryNames  = regexp.MustCompile(`(?i:\b(commentar(y|ies)|comentarios?|commentaires?|kommentare?|commento)\b)`)
+	descriptionNames = regexp.MustCompile(`(?i:audio ?descri|descriptive|\bdescribed\b)|\bAD\b`)
+	sdhNames         = regexp.MustCompile(`(?i:\bsdh\b|hearing impaired|\bsordos\b|closed captions?)|\b(CC|HI)\b`)
+	signsNames       = regexp.MustCompile(`(?i:\b(signs?|songs?|carteles|canciones)\b)`)
+)
+
+/*
+Kind classifies the track as main, commentary, audio description, SDH or signs
+and songs, based on its flags and its name. Forced subtitles are never taken as
+signs, as they're the ones shown by players when no other subtitle is chosen.
+*/
+func (track *Track) Kind() string {
+	properties := track.Properties
+	name := properties.TrackName
+
+	switch {
+	case properties.Commentary || commentaryNames.MatchString(name):
+		return KindCommentary
+	case properties.VisualImpaired || descriptionNames.MatchString(name):
+		return KindDescription
+	case properties.HearingImpaired || sdhNames.MatchString(name):
+		return KindSDH
+	case track.Type == "subtitles" && !properties.Forced && signsNames.MatchString(name):
+		return KindSigns
+	}
+
+	return KindMain
+}
+
+func kindFilter(kind string) func(TrackController) bool {
+	return func(track TrackController) bool {
+		return track.Track.Kind() == kind
+	}
+}
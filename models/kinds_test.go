@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestKindUsesFlagsAndTrackNames(t *testing.T) {
+	cases := []struct {
+		track    Track
+		expected string
+	}{
+		{Track{Type: "audio"}, KindMain},
+		{Track{Type: "audio", Properties: properties{TrackName: "Castellano 5.1"}}, KindMain},
+		{Track{Type: "audio", Properties: properties{Commentary: true}}, KindCommentary},
+		{Track{Type: "audio", Properties: properties{TrackName: "Comentarios del director"}}, KindCommentary},
+		{Track{Type: "audio", Properties: properties{VisualImpaired: true}}, KindDescription},
+		{Track{Type: "audio", Properties: properties{TrackName: "English (Audio Description)"}}, KindDescription},
+		{Track{Type: "audio", Properties: properties{TrackName: "English AD"}}, KindDescription},
+		{Track{Type: "audio", Properties: properties{TrackName: "Made in Hollywood, ad nauseam"}}, KindMain},
+		{Track{Type: "subtitles", Properties: properties{HearingImpaired: true}}, KindSDH},
+		{Track{Type: "subtitles", Properties: properties{TrackName: "English [SDH]"}}, KindSDH},
+		{Track{Type: "subtitles", Properties: properties{TrackName: "Español (para sordos)"}}, KindSDH},
+		{Track{Type: "subtitles", Properties: properties{TrackName: "CC"}}, KindSDH},
+		{Track{Type: "subtitles", Properties: properties{TrackName: "Signs & Songs"}}, KindSigns},
+		{Track{Type: "subtitles", Properties: properties{TrackName: "Signs", Forced: true}}, KindMain},
+	}
+
+	for _, test := range cases {
+		tests.Equals(t, test.expected, test.track.Kind())
+	}
+}
+
+func TestGetBestSubtitlesExcludesOptionalKinds(t *testing.T) {
+	subtitle := func(id uint, name string, forced bool) TrackController {
+		return TrackController{Track: &Track{ID: id, Type: "subtitles", Properties: properties{
+			Language:  "eng",
+			TrackName: name,
+			Forced:    forced,
+		}}}
+	}
+	sdh := subtitle(0, "English SDH", false)
+	forced := subtitle(1, "Forced", true)
+	full := subtitle(2, "English", false)
+	signs := subtitle(3, "Signs/Songs", false)
+	tracks := TracksController{Subtitles: Tracks{sdh, forced, full, signs}}
+
+	tests.Equals(t, Tracks{forced, full}, tracks.GetBestSubtitles([]string{"eng"}))
+
+	tracks.Preferences.IncludeKinds = []string{KindSDH, KindSigns}
+	tests.Equals(t, Tracks{forced, full, sdh, signs}, tracks.GetBestSubtitles([]string{"eng"}))
+}
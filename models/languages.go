@@ -0,0 +1,329 @@
+package models
+
+import (
+	"strings"
+)
+
+type language struct {
+	// ISO 639-1 code
+	Alpha2 string
+	// ISO 639-2/B code, the one used by mkvmerge
+	Bibliographic string
+	// ISO 639-2/T code, when it differs from the bibliographic one
+	Terminology string
+	// English and native names (lowercased)
+	Names []string
+}
+
+// All the ISO 639-1 languages, with their ISO 639-2 codes
+var languages = []language{
+	{"aa", "aar", "", []string{"afar"}},
+	{"ab", "abk", "", []string{"abkhazian"}},
+	{"ae", "ave", "", []string{"avestan"}},
+	{"af", "afr", "", []string{"afrikaans"}},
+	{"ak", "aka", "", []string{"akan"}},
+	{"am", "amh", "", []string{"amharic"}},
+	{"an", "arg", "", []string{"aragonese"}},
+	{"ar", "ara", "", []string{"arabic", "العربية"}},
+	{"as", "asm", "", []string{"assamese"}},
+	{"av", "ava", "", []string{"avaric"}},
+	{"ay", "aym", "", []string{"aymara"}},
+	{"az", "aze", "", []string{"azerbaijani", "azərbaycan"}},
+	{"ba", "bak", "", []string{"bashkir"}},
+	{"be", "bel", "", []string{"belarusian", "беларуская"}},
+	{"bg", "bul", "", []string{"bulgarian", "български"}},
+	{"bi", "bis", "", []string{"bislama"}},
+	{"bm", "bam", "", []string{"bambara"}},
+	{"bn", "ben", "", []string{"bengali", "bangla", "বাংলা"}},
+	{"bo", "tib", "bod", []string{"tibetan"}},
+	{"br", "bre", "", []string{"breton", "brezhoneg"}},
+	{"bs", "bos", "", []string{"bosnian", "bosanski"}},
+	{"ca", "cat", "", []string{"catalan", "valencian", "català", "catala", "catalán"}},
+	{"ce", "che", "", []string{"chechen"}},
+	{"ch", "cha", "", []string{"chamorro"}},
+	{"co", "cos", "", []string{"corsican", "corsu"}},
+	{"cr", "cre", "", []string{"cree"}},
+	{"cs", "cze", "ces", []string{"czech", "čeština", "cestina"}},
+	{"cu", "chu", "", []string{"church slavic", "old church slavonic"}},
+	{"cv", "chv", "", []string{"chuvash"}},
+	{"cy", "wel", "cym", []string{"welsh", "cymraeg"}},
+	{"da", "dan", "", []string{"danish", "dansk"}},
+	{"de", "ger", "deu", []string{"german", "deutsch", "alemán", "aleman", "allemand"}},
+	{"dv", "div", "", []string{"divehi", "dhivehi", "maldivian"}},
+	{"dz", "dzo", "", []string{"dzongkha"}},
+	{"ee", "ewe", "", []string{"ewe"}},
+	{"el", "gre", "ell", []string{"greek", "ελληνικά", "griego"}},
+	{"en", "eng", "", []string{"english", "inglés", "ingles", "anglais", "englisch"}},
+	{"eo", "epo", "", []string{"esperanto"}},
+	{"es", "spa", "", []string{"spanish", "castilian", "español", "espanol", "castellano", "latino", "espagnol", "spanisch"}},
+	{"et", "est", "", []string{"estonian", "eesti"}},
+	{"eu", "baq", "eus", []string{"basque", "euskara", "euskera", "vasco"}},
+	{"fa", "per", "fas", []string{"persian", "farsi", "فارسی"}},
+	{"ff", "ful", "", []string{"fulah"}},
+	{"fi", "fin", "", []string{"finnish", "suomi"}},
+	{"fj", "fij", "", []string{"fijian"}},
+	{"fo", "fao", "", []string{"faroese", "føroyskt"}},
+	{"fr", "fre", "fra", []string{"french", "français", "francais", "francés", "frances", "französisch"}},
+	{"fy", "fry", "", []string{"western frisian", "frisian"}},
+	{"ga", "gle", "", []string{"irish", "gaeilge"}},
+	{"gd", "gla", "", []string{"gaelic", "scottish gaelic", "gàidhlig"}},
+	{"gl", "glg", "", []string{"galician", "galego", "gallego"}},
+	{"gn", "grn", "", []string{"guarani"}},
+	{"gu", "guj", "", []string{"gujarati"}},
+	{"gv", "glv", "", []string{"manx"}},
+	{"ha", "hau", "", []string{"hausa"}},
+	{"he", "heb", "", []string{"hebrew", "עברית"}},
+	{"hi", "hin", "", []string{"hindi", "हिन्दी"}},
+	{"ho", "hmo", "", []string{"hiri motu"}},
+	{"hr", "hrv", "", []string{"croatian", "hrvatski"}},
+	{"ht", "hat", "", []string{"haitian", "haitian creole"}},
+	{"hu", "hun", "", []string{"hungarian", "magyar"}},
+	{"hy", "arm", "hye", []string{"armenian", "հայերեն"}},
+	{"hz", "her", "", []string{"herero"}},
+	{"ia", "ina", "", []string{"interlingua"}},
+	{"id", "ind", "", []string{"indonesian", "bahasa indonesia"}},
+	{"ie", "ile", "", []string{"interlingue", "occidental"}},
+	{"ig", "ibo", "", []string{"igbo"}},
+	{"ii", "iii", "", []string{"sichuan yi", "nuosu"}},
+	{"ik", "ipk", "", []string{"inupiaq"}},
+	{"io", "ido", "", []string{"ido"}},
+	{"is", "ice", "isl", []string{"icelandic", "íslenska"}},
+	{"it", "ita", "", []string{"italian", "italiano", "italien", "italienisch"}},
+	{"iu", "iku", "", []string{"inuktitut"}},
+	{"ja", "jpn", "", []string{"japanese", "日本語", "japonés", "japones", "japonais"}},
+	{"jv", "jav", "", []string{"javanese"}},
+	{"ka", "geo", "kat", []string{"georgian", "ქართული"}},
+	{"kg", "kon", "", []string{"kongo"}},
+	{"ki", "kik", "", []string{"kikuyu"}},
+	{"kj", "kua", "", []string{"kuanyama"}},
+	{"kk", "kaz", "", []string{"kazakh", "қазақ"}},
+	{"kl", "kal", "", []string{"kalaallisut", "greenlandic"}},
+	{"km", "khm", "", []string{"khmer", "central khmer"}},
+	{"kn", "kan", "", []string{"kannada"}},
+	{"ko", "kor", "", []string{"korean", "한국어", "coreano"}},
+	{"kr", "kau", "", []string{"kanuri"}},
+	{"ks", "kas", "", []string{"kashmiri"}},
+	{"ku", "kur", "", []string{"kurdish", "kurdî"}},
+	{"kv", "kom", "", []string{"komi"}},
+	{"kw", "cor", "", []string{"cornish"}},
+	{"ky", "kir", "", []string{"kirghiz", "kyrgyz"}},
+	{"la", "lat", "", []string{"latin"}},
+	{"lb", "ltz", "", []string{"luxembourgish", "lëtzebuergesch"}},
+	{"lg", "lug", "", []string{"ganda"}},
+	{"li", "lim", "", []string{"limburgish"}},
+	{"ln", "lin", "", []string{"lingala"}},
+	{"lo", "lao", "", []string{"lao"}},
+	{"lt", "lit", "", []string{"lithuanian", "lietuvių"}},
+	{"lu", "lub", "", []string{"luba-katanga"}},
+	{"lv", "lav", "", []string{"latvian", "latviešu"}},
+	{"mg", "mlg", "", []string{"malagasy"}},
+	{"mh", "mah", "", []string{"marshallese"}},
+	{"mi", "mao", "mri", []string{"maori", "māori"}},
+	{"mk", "mac", "mkd", []string{"macedonian", "македонски"}},
+	{"ml", "mal", "", []string{"malayalam"}},
+	{"mn", "mon", "", []string{"mongolian", "монгол"}},
+	{"mr", "mar", "", []string{"marathi"}},
+	{"ms", "may", "msa", []string{"malay", "bahasa melayu"}},
+	{"mt", "mlt", "", []string{"maltese", "malti"}},
+	{"my", "bur", "mya", []string{"burmese", "myanmar"}},
+	{"na", "nau", "", []string{"nauru"}},
+	{"nb", "nob", "", []string{"norwegian bokmål", "bokmål", "bokmal"}},
+	{"nd", "nde", "", []string{"north ndebele"}},
+	{"ne", "nep", "", []string{"nepali"}},
+	{"ng", "ndo", "", []string{"ndonga"}},
+	{"nl", "dut", "nld", []string{"dutch", "flemish", "nederlands", "vlaams", "holandés"}},
+	{"nn", "nno", "", []string{"norwegian nynorsk", "nynorsk"}},
+	{"no", "nor", "", []string{"norwegian", "norsk", "noruego"}},
+	{"nr", "nbl", "", []string{"south ndebele"}},
+	{"nv", "nav", "", []string{"navajo"}},
+	{"ny", "nya", "", []string{"chichewa", "nyanja"}},
+	{"oc", "oci", "", []string{"occitan", "occità"}},
+	{"oj", "oji", "", []string{"ojibwa"}},
+	{"om", "orm", "", []string{"oromo"}},
+	{"or", "ori", "", []string{"oriya", "odia"}},
+	{"os", "oss", "", []string{"ossetian"}},
+	{"pa", "pan", "", []string{"punjabi", "panjabi"}},
+	{"pi", "pli", "", []string{"pali"}},
+	{"pl", "pol", "", []string{"polish", "polski", "polaco"}},
+	{"ps", "pus", "", []string{"pashto", "pushto"}},
+	{"pt", "por", "", []string{"portuguese", "português", "portugues", "portugués"}},
+	{"qu", "que", "", []string{"quechua"}},
+	{"rm", "roh", "", []string{"romansh"}},
+	{"rn", "run", "", []string{"rundi"}},
+	{"ro", "rum", "ron", []string{"romanian", "moldavian", "română", "romana", "rumano"}},
+	{"ru", "rus", "", []string{"russian", "русский", "ruso", "russe"}},
+	{"rw", "kin", "", []string{"kinyarwanda"}},
+	{"sa", "san", "", []string{"sanskrit"}},
+	{"sc", "srd", "", []string{"sardinian", "sardu"}},
+	{"sd", "snd", "", []string{"sindhi"}},
+	{"se", "sme", "", []string{"northern sami"}},
+	{"sg", "sag", "", []string{"sango"}},
+	{"si", "sin", "", []string{"sinhala", "sinhalese"}},
+	{"sk", "slo", "slk", []string{"slovak", "slovenčina", "slovencina"}},
+	{"sl", "slv", "", []string{"slovenian", "slovene", "slovenščina"}},
+	{"sm", "smo", "", []string{"samoan"}},
+	{"sn", "sna", "", []string{"shona"}},
+	{"so", "som", "", []string{"somali"}},
+	{"sq", "alb", "sqi", []string{"albanian", "shqip"}},
+	{"sr", "srp", "", []string{"serbian", "српски", "srpski"}},
+	{"ss", "ssw", "", []string{"swati"}},
+	{"st", "sot", "", []string{"southern sotho"}},
+	{"su", "sun", "", []string{"sundanese"}},
+	{"sv", "swe", "", []string{"swedish", "svenska", "sueco"}},
+	{"sw", "swa", "", []string{"swahili", "kiswahili"}},
+	{"ta", "tam", "", []string{"tamil", "தமிழ்"}},
+	{"te", "tel", "", []string{"telugu"}},
+	{"tg", "tgk", "", []string{"tajik"}},
+	{"th", "tha", "", []string{"thai", "ไทย"}},
+	{"ti", "tir", "", []string{"tigrinya"}},
+	{"tk", "tuk", "", []string{"turkmen"}},
+	{"tl", "tgl", "", []string{"tagalog", "filipino"}},
+	{"tn", "tsn", "", []string{"tswana"}},
+	{"to", "ton", "", []string{"tonga"}},
+	{"tr", "tur", "", []string{"turkish", "türkçe", "turkce", "turco"}},
+	{"ts", "tso", "", []string{"tsonga"}},
+	{"tt", "tat", "", []string{"tatar"}},
+	{"tw", "twi", "", []string{"twi"}},
+	{"ty", "tah", "", []string{"tahitian"}},
+	{"ug", "uig", "", []string{"uighur", "uyghur"}},
+	{"uk", "ukr", "", []string{"ukrainian", "українська", "ucraniano"}},
+	{"ur", "urd", "", []string{"urdu", "اردو"}},
+	{"uz", "uzb", "", []string{"uzbek", "oʻzbek"}},
+	{"ve", "ven", "", []string{"venda"}},
+	{"vi", "vie", "", []string{"vietnamese", "tiếng việt"}},
+	{"vo", "vol", "", []string{"volapük", "volapuk"}},
+	{"wa", "wln", "", []string{"walloon"}},
+	{"wo", "wol", "", []string{"wolof"}},
+	{"xh", "xho", "", []string{"xhosa"}},
+	{"yi", "yid", "", []string{"yiddish", "ייִדיש"}},
+	{"yo", "yor", "", []string{"yoruba"}},
+	{"za", "zha", "", []string{"zhuang"}},
+	{"zh", "chi", "zho", []string{"chinese", "mandarin", "cantonese", "中文", "chino"}},
+	{"zu", "zul", "", []string{"zulu"}},
+}
+
+var (
+	languagesByCode map[string]*language
+	languagesByName map[string]*language
+)
+
+func init() {
+	languagesByCode = map[string]*language{}
+	languagesByName = map[string]*language{}
+
+	for i := range languages {
+		language := &languages[i]
+		languagesByCode[language.Alpha2] = language
+		languagesByCode[language.Bibliographic] = language
+		if language.Terminology != "" {
+			languagesByCode[language.Terminology] = language
+		}
+
+		for _, name := range language.Names {
+			languagesByName[name] = language
+		}
+	}
+}
+
+// findLanguage looks for the language with the given code or name, telling
+// whether it was found by its name
+func findLanguage(token string) (language *language, byName bool) {
+	token = strings.ToLower(strings.TrimSpace(token))
+	if language, ok := languagesByCode[token]; ok {
+		return language, false
+	}
+
+	return languagesByName[token], true
+}
+
+/*
+LanguageFromToken returns the ISO 639-2/B code of the language represented by
+the given code or name (like "es", "spa" or "Castellano"), or an empty string
+if it's not a known language
+*/
+func LanguageFromToken(token string) string {
+	if language, _ := findLanguage(token); language != nil {
+		return language.Bibliographic
+	}
+
+	return ""
+}
+
+/*
+NormalizeLanguage returns the ISO 639-2/B code for the given ISO 639-1,
+ISO 639-2/B or ISO 639-2/T code or BCP-47 tag (like "de", "deu" or "de-AT",
+which are all "ger"). Unknown languages are returned lowercased.
+*/
+func NormalizeLanguage(code string) string {
+	primary, _ := splitLanguageTag(code)
+	if language, _ := findLanguage(primary); language != nil {
+		return language.Bibliographic
+	}
+
+	return primary
+}
+
+// splitLanguageTag splits a BCP-47 tag (like "es-419" or "zh-Hant-TW") into
+// its primary language and its (lowercased) subtags
+func splitLanguageTag(tag string) (primary, subtags string) {
+	tag = strings.ToLower(strings.TrimSpace(strings.Replace(tag, "_", "-", -1)))
+	parts := strings.SplitN(tag, "-", 2)
+	if len(parts) == 2 {
+		subtags = parts[1]
+	}
+
+	return parts[0], subtags
+}
+
+/*
+MatchLanguage ranks how well the track matches the given language code or
+BCP-47 tag: 0 when it doesn't match, 1 when its language matches and 2 when
+its IETF tag also matches the requested subtags (like "419" for "es-419").
+Tracks without IETF subtags match any of them, but tracks with different ones
+(like "es-ES") don't.
+*/
+func (track *Track) MatchLanguage(language string) int {
+	if NormalizeLanguage(language) != NormalizeLanguage(track.Properties.Language) {
+		return 0
+	}
+
+	_, requested := splitLanguageTag(language)
+	subtags := track.ietfSubtags()
+	if requested == "" || subtags == "" {
+		return 1
+	}
+
+	if subtags == requested || strings.HasPrefix(subtags, requested+"-") {
+		return 2
+	}
+
+	return 0
+}
+
+/*
+GetLanguage returns the language to be set to the output: its IETF tag when it
+has subtags (like "es-419"), its ISO 639-2/B code otherwise.
+*/
+func (track *Track) GetLanguage() string {
+	if track.ietfSubtags() != "" {
+		return track.Properties.LanguageIETF
+	}
+
+	if track.HasUndefinedLanguage() {
+		return "und"
+	}
+
+	return NormalizeLanguage(track.Properties.Language)
+}
+
+// ietfSubtags returns the subtags of the track IETF tag, as long as it's for
+// the same language set in the track (they may differ when the language has
+// been changed)
+func (track *Track) ietfSubtags() string {
+	primary, subtags := splitLanguageTag(track.Properties.LanguageIETF)
+	if NormalizeLanguage(primary) != NormalizeLanguage(track.Properties.Language) {
+		return ""
+	}
+
+	return subtags
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	cases := map[string]string{
+		"es":         "spa",
+		"spa":        "spa",
+		"es-419":     "spa",
+		"de":         "ger",
+		"deu":        "ger",
+		"GER":        "ger",
+		"zh-Hant-TW": "chi",
+		"pt_BR":      "por",
+		"und":        "und",
+		"tlh":        "tlh",
+	}
+
+	for code, expected := range cases {
+		tests.Equals(t, expected, NormalizeLanguage(code))
+	}
+}
+
+func TestMatchLanguageUsesIETFSubtags(t *testing.T) {
+	castilian := &Track{Properties: properties{Language: "spa", LanguageIETF: "es-ES"}}
+	latin := &Track{Properties: properties{Language: "spa", LanguageIETF: "es-419"}}
+	spanish := &Track{Properties: properties{Language: "spa"}}
+	german := &Track{Properties: properties{Language: "deu", LanguageIETF: "de"}}
+
+	tests.Equals(t, 1, castilian.MatchLanguage("es"))
+	tests.Equals(t, 2, castilian.MatchLanguage("es-ES"))
+	tests.Equals(t, 0, castilian.MatchLanguage("es-419"))
+	tests.Equals(t, 2, latin.MatchLanguage("spa-419"))
+	tests.Equals(t, 1, spanish.MatchLanguage("es-419"))
+	tests.Equals(t, 1, german.MatchLanguage("ger"))
+	tests.Equals(t, 0, german.MatchLanguage("eng"))
+
+	tests.Equals(t, "es-419", latin.GetLanguage())
+	tests.Equals(t, "spa", spanish.GetLanguage())
+	tests.Equals(t, "ger", german.GetLanguage())
+	tests.Equals(t, "und", (&Track{}).GetLanguage())
+}
+
+func TestGetBestAudiosDistinguishesSpanishVariants(t *testing.T) {
+	castilian := TrackController{
+		Input: &Info{Position: 1},
+		Track: &Track{ID: 1, Properties: properties{Language: "spa", LanguageIETF: "es-ES"}},
+	}
+	latin := TrackController{
+		Input: &Info{Position: 0},
+		Track: &Track{ID: 2, Properties: properties{Language: "spa", LanguageIETF: "es-419"}},
+	}
+	english := TrackController{
+		Input: &Info{Position: 0},
+		Track: &Track{ID: 3, Properties: properties{Language: "eng"}},
+	}
+	tracks := TracksController{Audios: Tracks{castilian, latin, english}}
+
+	audios, err := tracks.GetBestAudios([]string{"es-419", "en", "eng"})
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{latin, english}, audios)
+
+	audios, err = tracks.GetBestAudios([]string{"es"})
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{castilian}, audios)
+
+	_, err = tracks.GetBestAudios([]string{"es-MX"})
+	tests.Equals(t, &LanguageNotFoundError{Type: "audio", Language: "es-MX"}, err)
+}
@@ -0,0 +1,908 @@
+package models
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Matroska element IDs used by the native reader
+const (
+	mkvEBML            = 0x1A45DFA3
+	mkvDocType         = 0x4282
+	mkvSegment         = 0x18538067
+	mkvSeekHead        = 0x114D9B74
+	mkvSeek            = 0x4DBB
+	mkvSeekID          = 0x53AB
+	mkvSeekPosition    = 0x53AC
+	mkvInfo            = 0x1549A966
+	mkvTimestampScale  = 0x2AD7B1
+	mkvDuration        = 0x4489
+	mkvTitle           = 0x7BA9
+	mkvMuxingApp       = 0x4D80
+	mkvWritingApp      = 0x5741
+	mkvSegmentUID      = 0x73A4
+	mkvDateUTC         = 0x4461
+	mkvTracks          = 0x1654AE6B
+	mkvTrackEntry      = 0xAE
+	mkvTrackNumber     = 0xD7
+	mkvTrackUID        = 0x73C5
+	mkvTrackType       = 0x83
+	mkvFlagDefault     = 0x88
+	mkvFlagForced      = 0x55AA
+	mkvFlagHearingImp  = 0x55AB
+	mkvFlagVisualImp   = 0x55AC
+	mkvFlagOriginal    = 0x55AE
+	mkvFlagCommentary  = 0x55AF
+	mkvDefaultDuration = 0x23E383
+	mkvName            = 0x536E
+	mkvLanguage        = 0x22B59C
+	mkvLanguageIETF    = 0x22B59D
+	mkvCodecID         = 0x86
+	mkvCodecPrivate    = 0x63A2
+	mkvVideo           = 0xE0
+	mkvPixelWidth      = 0xB0
+	mkvPixelHeight     = 0xBA
+	mkvDisplayWidth    = 0x54B0
+	mkvDisplayHeight   = 0x54BA
+	mkvDisplayUnit     = 0x54B2
+	mkvColour          = 0x55B0
+	mkvBitsPerChannel  = 0x55B2
+	mkvTransferChars   = 0x55BA
+	mkvBlockAddMapping = 0x41E4
+	mkvBlockAddIDType  = 0x41E7
+	mkvAudio           = 0xE1
+	mkvSamplingFreq    = 0xB5
+	mkvChannels        = 0x9F
+	mkvBitDepth        = 0x6264
+	mkvEmphasis        = 0x52F1
+	mkvChapters        = 0x1043A770
+	mkvEditionEntry    = 0x45B9
+	mkvChapterAtom     = 0xB6
+	mkvTags            = 0x1254C367
+	mkvTag             = 0x7373
+	mkvTargets         = 0x63C0
+	mkvTagTrackUID     = 0x63C5
+	mkvSimpleTag       = 0x67C8
+	mkvTagName         = 0x45A3
+	mkvTagString       = 0x4487
+	mkvAttachments     = 0x1941A469
+	mkvAttachedFile    = 0x61A7
+	mkvFileName        = 0x466E
+	mkvFileMimeType    = 0x4660
+	mkvFileDescription = 0x467E
+	mkvFileData        = 0x465C
+	mkvFileUID         = 0x46AE
+	mkvCluster         = 0x1F43B675
+	mkvContentEncs     = 0x6D80
+	mkvContentEnc      = 0x6240
+	mkvContentComp     = 0x5034
+	mkvContentCompAlgo = 0x4254
+	mkvContentCompSets = 0x4255
+	mkvBlockGroup      = 0xA0
+	mkvBlock           = 0xA1
+	mkvSimpleBlock     = 0xA3
+)
+
+// Matroska content compression algorithms (-1 when not compressed)
+const (
+	mkvCompressionNone            = -1
+	mkvCompressionZlib            = 0
+	mkvCompressionHeaderStripping = 3
+)
+
+// Block addition types of the Dolby Vision configuration records
+var dolbyVisionRecords = map[uint64]bool{
+	0x64766343: true, // dvcC
+	0x64767643: true, // dvvC
+	0x64767743: true, // dvwC
+}
+
+// Matroska track types
+const (
+	mkvTrackTypeVideo     = 1
+	mkvTrackTypeAudio     = 2
+	mkvTrackTypeSubtitles = 0x11
+)
+
+type matroskaTrack struct {
+	Number          uint64
+	UID             uint64
+	Type            uint64
+	Default         bool
+	Forced          bool
+	HearingImpaired bool
+	VisualImpaired  bool
+	Original        bool
+	Commentary      bool
+	DefaultDuration uint64
+	Name            string
+	Language        string
+	LanguageIETF    string
+	CodecID         string
+	CodecPrivate    []byte
+	PixelWidth      uint64
+	PixelHeight     uint64
+	DisplayWidth    uint64
+	DisplayHeight   uint64
+	DisplayUnit     uint64
+	BitsPerChannel  uint64
+	TransferChars   uint64
+	DolbyVision     bool
+	SamplingFreq    float64
+	Channels        uint64
+	BitDepth        uint64
+	Emphasis        uint64
+	StatisticsTags  map[string]string
+	Compression     int
+	CompressionSets []byte
+}
+
+type matroskaAttachment struct {
+	UID         uint64
+	Name        string
+	MimeType    string
+	Description string
+	Size        int64
+}
+
+type matroskaFile struct {
+	TimestampScale  uint64
+	Duration        float64
+	Title           string
+	MuxingApp       string
+	WritingApp      string
+	SegmentUID      []byte
+	DateUTC         int64
+	Tracks          []matroskaTrack
+	ChapterEditions []int
+	GlobalTags      int
+	Attachments     []matroskaAttachment
+	// Position of the first element of the segment
+	SegmentStart int64
+}
+
+/*
+MatroskaProber identifies Matroska (.mkv, .mka, .mks) and WebM files natively,
+without spawning any external tool.
+*/
+type MatroskaProber struct{}
+
+/*
+Probe the given Matroska file
+*/
+func (MatroskaProber) Probe(file string) (information Info, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	mkv, err := readMatroska(f)
+	if err != nil {
+		return
+	}
+
+	information = mkv.info()
+	information.FileName = file
+
+	fi, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	information.FileSize = fi.Size()
+
+	return information, nil
+}
+
+// readMatroska reads all the metadata elements of a Matroska segment.
+func readMatroska(r io.ReadSeeker) (mkv *matroskaFile, err error) {
+	header, err := readEBMLHeader(r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == errInvalidVint {
+		return nil, errUnsupportedContainer
+	}
+	if err != nil {
+		return
+	}
+	if header.ID != mkvEBML || header.Size == ebmlUnknownSize {
+		return nil, errUnsupportedContainer
+	}
+
+	data, err := readEBMLData(r, header.Size)
+	if err != nil {
+		return
+	}
+
+	children, err := ebmlChildren(data)
+	if err != nil {
+		return
+	}
+
+	for _, child := range children {
+		if child.ID == mkvDocType && child.string() != "matroska" && child.string() != "webm" {
+			return nil, errUnsupportedContainer
+		}
+	}
+
+	header, err = readEBMLHeader(r)
+	if err != nil {
+		return
+	}
+	if header.ID != mkvSegment {
+		return nil, errors.New("matroska segment not found")
+	}
+
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return
+	}
+
+	end := int64(-1)
+	if header.Size != ebmlUnknownSize {
+		end = start + header.Size
+	}
+
+	reader := &matroskaReader{
+		r:       r,
+		start:   start,
+		visited: map[int64]bool{},
+		mkv:     &matroskaFile{TimestampScale: 1000000, SegmentStart: start},
+	}
+
+	if err = reader.scan(end); err != nil {
+		return
+	}
+
+	return reader.mkv, nil
+}
+
+type matroskaReader struct {
+	r       io.ReadSeeker
+	start   int64
+	pending []int64
+	visited map[int64]bool
+	mkv     *matroskaFile
+}
+
+// scan reads the top level elements of the segment in order until the first
+// cluster is found and, from there, jumps to the elements referenced by the
+// seek heads, so we don't need to read the whole file.
+func (reader *matroskaReader) scan(end int64) error {
+	pos := reader.start
+
+	for end < 0 || pos < end {
+		next, cluster, err := reader.element(pos)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Elements placed after the clusters are (usually) referenced by the
+		// seek heads, so we only need to go through clusters without them.
+		if next < 0 || (cluster && len(reader.pending) > 0) {
+			break
+		}
+
+		pos = next
+	}
+
+	for len(reader.pending) > 0 {
+		pos, reader.pending = reader.pending[0], reader.pending[1:]
+		if _, _, err := reader.element(pos); err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// element parses the top level element found at pos, returning the position
+// of the next one (-1 if unknown).
+func (reader *matroskaReader) element(pos int64) (next int64, cluster bool, err error) {
+	if reader.visited[pos] {
+		return -1, false, nil
+	}
+	reader.visited[pos] = true
+
+	if _, err = reader.r.Seek(pos, io.SeekStart); err != nil {
+		return
+	}
+
+	header, err := readEBMLHeader(reader.r)
+	if err != nil {
+		return
+	}
+
+	next = pos + header.HeaderSize + header.Size
+	if header.Size == ebmlUnknownSize {
+		next = -1
+	}
+
+	switch header.ID {
+	case mkvCluster:
+		return next, true, nil
+	case mkvAttachments:
+		err = reader.attachments(pos+header.HeaderSize, header.Size)
+		return
+	case mkvSeekHead, mkvInfo, mkvTracks, mkvChapters, mkvTags:
+	default:
+		return
+	}
+
+	if header.Size == ebmlUnknownSize {
+		return next, false, fmt.Errorf("unknown size for element %X", header.ID)
+	}
+
+	data, err := readEBMLData(reader.r, header.Size)
+	if err != nil {
+		return
+	}
+
+	children, err := ebmlChildren(data)
+	if err != nil {
+		return
+	}
+
+	mkv := reader.mkv
+	switch header.ID {
+	case mkvSeekHead:
+		reader.seekHead(children)
+	case mkvInfo:
+		mkv.segmentInfo(children)
+	case mkvTracks:
+		err = mkv.tracks(children)
+	case mkvChapters:
+		err = mkv.chapters(children)
+	case mkvTags:
+		err = mkv.tags(children)
+	}
+
+	return
+}
+
+func (reader *matroskaReader) seekHead(children []ebmlElement) {
+	for _, seek := range children {
+		if seek.ID != mkvSeek {
+			continue
+		}
+
+		entries, err := seek.children()
+		if err != nil {
+			continue
+		}
+
+		var id uint64
+		position := int64(-1)
+		for _, entry := range entries {
+			switch entry.ID {
+			case mkvSeekID:
+				id = entry.uint()
+			case mkvSeekPosition:
+				position = int64(entry.uint())
+			}
+		}
+
+		switch id {
+		case mkvSeekHead, mkvInfo, mkvTracks, mkvChapters, mkvTags, mkvAttachments:
+			if position >= 0 {
+				reader.pending = append(reader.pending, reader.start+position)
+			}
+		}
+	}
+}
+
+// attachments are read one by one, skipping the attached files' data.
+func (reader *matroskaReader) attachments(start, size int64) error {
+	if size == ebmlUnknownSize {
+		return errors.New("unknown size for attachments")
+	}
+
+	for pos := start; pos < start+size; {
+		if _, err := reader.r.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+
+		header, err := readEBMLHeader(reader.r)
+		if err != nil {
+			return err
+		}
+		if header.Size == ebmlUnknownSize {
+			return errors.New("unknown size for attached file")
+		}
+
+		if header.ID == mkvAttachedFile {
+			attachment, err := reader.attachedFile(pos+header.HeaderSize, header.Size)
+			if err != nil {
+				return err
+			}
+			reader.mkv.Attachments = append(reader.mkv.Attachments, attachment)
+		}
+
+		pos += header.HeaderSize + header.Size
+	}
+
+	return nil
+}
+
+func (reader *matroskaReader) attachedFile(start, size int64) (attachment matroskaAttachment, err error) {
+	for pos := start; pos < start+size; {
+		if _, err = reader.r.Seek(pos, io.SeekStart); err != nil {
+			return
+		}
+
+		header, err := readEBMLHeader(reader.r)
+		if err != nil {
+			return attachment, err
+		}
+		if header.Size == ebmlUnknownSize {
+			return attachment, errors.New("unknown size for attached file")
+		}
+
+		pos += header.HeaderSize + header.Size
+
+		if header.ID == mkvFileData {
+			attachment.Size = header.Size
+			continue
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(reader.r, header.Size))
+		if err != nil {
+			return attachment, err
+		}
+
+		element := ebmlElement{ID: header.ID, Data: data}
+		switch header.ID {
+		case mkvFileName:
+			attachment.Name = element.string()
+		case mkvFileMimeType:
+			attachment.MimeType = element.string()
+		case mkvFileDescription:
+			attachment.Description = element.string()
+		case mkvFileUID:
+			attachment.UID = element.uint()
+		}
+	}
+
+	return
+}
+
+func (mkv *matroskaFile) segmentInfo(children []ebmlElement) {
+	for _, child := range children {
+		switch child.ID {
+		case mkvTimestampScale:
+			mkv.TimestampScale = child.uint()
+		case mkvDuration:
+			mkv.Duration = child.float()
+		case mkvTitle:
+			mkv.Title = child.string()
+		case mkvMuxingApp:
+			mkv.MuxingApp = child.string()
+		case mkvWritingApp:
+			mkv.WritingApp = child.string()
+		case mkvSegmentUID:
+			mkv.SegmentUID = child.Data
+		case mkvDateUTC:
+			mkv.DateUTC = int64(child.uint())
+		}
+	}
+}
+
+// chapters counts the chapters of each edition.
+func (mkv *matroskaFile) chapters(children []ebmlElement) error {
+	for _, child := range children {
+		if child.ID != mkvEditionEntry {
+			continue
+		}
+
+		atoms, err := child.children()
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, atom := range atoms {
+			if atom.ID == mkvChapterAtom {
+				count++
+			}
+		}
+
+		mkv.ChapterEditions = append(mkv.ChapterEditions, count)
+	}
+
+	return nil
+}
+
+func (mkv *matroskaFile) tracks(children []ebmlElement) error {
+	for _, child := range children {
+		if child.ID != mkvTrackEntry {
+			continue
+		}
+
+		entries, err := child.children()
+		if err != nil {
+			return err
+		}
+
+		// Default values, as defined by the Matroska specs
+		track := matroskaTrack{
+			Default:      true,
+			Language:     "eng",
+			SamplingFreq: 8000,
+			Channels:     1,
+			Compression:  mkvCompressionNone,
+		}
+
+		for _, entry := range entries {
+			switch entry.ID {
+			case mkvTrackNumber:
+				track.Number = entry.uint()
+			case mkvTrackUID:
+				track.UID = entry.uint()
+			case mkvTrackType:
+				track.Type = entry.uint()
+			case mkvFlagDefault:
+				track.Default = entry.uint() == 1
+			case mkvFlagForced:
+				track.Forced = entry.uint() == 1
+			case mkvFlagHearingImp:
+				track.HearingImpaired = entry.uint() == 1
+			case mkvFlagVisualImp:
+				track.VisualImpaired = entry.uint() == 1
+			case mkvFlagOriginal:
+				track.Original = entry.uint() == 1
+			case mkvFlagCommentary:
+				track.Commentary = entry.uint() == 1
+			case mkvDefaultDuration:
+				track.DefaultDuration = entry.uint()
+			case mkvName:
+				track.Name = entry.string()
+			case mkvLanguage:
+				track.Language = entry.string()
+			case mkvLanguageIETF:
+				track.LanguageIETF = entry.string()
+			case mkvCodecID:
+				track.CodecID = entry.string()
+			case mkvCodecPrivate:
+				track.CodecPrivate = entry.Data
+			case mkvContentEncs:
+				if err = track.contentEncodings(entry); err != nil {
+					return err
+				}
+			case mkvBlockAddMapping:
+				if err = track.blockAdditionMapping(entry); err != nil {
+					return err
+				}
+			case mkvVideo, mkvAudio:
+				if err = track.settings(entry); err != nil {
+					return err
+				}
+			}
+		}
+
+		mkv.Tracks = append(mkv.Tracks, track)
+	}
+
+	return nil
+}
+
+func (track *matroskaTrack) settings(element ebmlElement) error {
+	children, err := element.children()
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		switch child.ID {
+		case mkvPixelWidth:
+			track.PixelWidth = child.uint()
+		case mkvPixelHeight:
+			track.PixelHeight = child.uint()
+		case mkvDisplayWidth:
+			track.DisplayWidth = child.uint()
+		case mkvDisplayHeight:
+			track.DisplayHeight = child.uint()
+		case mkvDisplayUnit:
+			track.DisplayUnit = child.uint()
+		case mkvColour:
+			colour, err := child.children()
+			if err != nil {
+				return err
+			}
+			for _, property := range colour {
+				switch property.ID {
+				case mkvBitsPerChannel:
+					track.BitsPerChannel = property.uint()
+				case mkvTransferChars:
+					track.TransferChars = property.uint()
+				}
+			}
+		case mkvSamplingFreq:
+			track.SamplingFreq = child.float()
+		case mkvChannels:
+			track.Channels = child.uint()
+		case mkvBitDepth:
+			track.BitDepth = child.uint()
+		case mkvEmphasis:
+			track.Emphasis = child.uint()
+		}
+	}
+
+	return nil
+}
+
+// blockAdditionMapping detects the Dolby Vision configuration records
+func (track *matroskaTrack) blockAdditionMapping(element ebmlElement) error {
+	children, err := element.children()
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if child.ID == mkvBlockAddIDType && dolbyVisionRecords[child.uint()] {
+			track.DolbyVision = true
+		}
+	}
+
+	return nil
+}
+
+// contentEncodings reads the compression used by the track blocks
+func (track *matroskaTrack) contentEncodings(element ebmlElement) error {
+	encodings, err := element.children()
+	if err != nil {
+		return err
+	}
+
+	for _, encoding := range encodings {
+		if encoding.ID != mkvContentEnc {
+			continue
+		}
+
+		children, err := encoding.children()
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			if child.ID != mkvContentComp {
+				continue
+			}
+
+			compression, err := child.children()
+			if err != nil {
+				return err
+			}
+
+			// zlib is the default algorithm
+			track.Compression = mkvCompressionZlib
+			for _, setting := range compression {
+				switch setting.ID {
+				case mkvContentCompAlgo:
+					track.Compression = int(setting.uint())
+				case mkvContentCompSets:
+					track.CompressionSets = setting.Data
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// tags extracts the statistics tags of each track, counting the global ones.
+func (mkv *matroskaFile) tags(children []ebmlElement) error {
+	for _, child := range children {
+		if child.ID != mkvTag {
+			continue
+		}
+
+		entries, err := child.children()
+		if err != nil {
+			return err
+		}
+
+		var uids []uint64
+		simple := map[string]string{}
+		for _, entry := range entries {
+			switch entry.ID {
+			case mkvTargets:
+				targets, err := entry.children()
+				if err != nil {
+					return err
+				}
+				for _, target := range targets {
+					if target.ID == mkvTagTrackUID {
+						uids = append(uids, target.uint())
+					}
+				}
+			case mkvSimpleTag:
+				name, value := simpleTag(entry)
+				if name != "" {
+					simple[name] = value
+				}
+			}
+		}
+
+		if len(uids) == 0 {
+			mkv.GlobalTags += len(simple)
+			continue
+		}
+
+		for _, uid := range uids {
+			for i := range mkv.Tracks {
+				if uid != 0 && mkv.Tracks[i].UID != uid {
+					continue
+				}
+				if mkv.Tracks[i].StatisticsTags == nil {
+					mkv.Tracks[i].StatisticsTags = map[string]string{}
+				}
+				for name, value := range simple {
+					mkv.Tracks[i].StatisticsTags[name] = value
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func simpleTag(element ebmlElement) (name, value string) {
+	children, err := element.children()
+	if err != nil {
+		return
+	}
+
+	for _, child := range children {
+		switch child.ID {
+		case mkvTagName:
+			// Old mkvmerge versions used to write language suffixed names (BPS-eng)
+			name = strings.ToUpper(strings.TrimSuffix(child.string(), "-eng"))
+		case mkvTagString:
+			value = child.string()
+		}
+	}
+
+	return
+}
+
+// info maps the Matroska data to the same structure mkvmerge's JSON
+// identification output produces.
+func (mkv *matroskaFile) info() (information Info) {
+	information.Container.Recognized = true
+	information.Container.Supported = true
+
+	properties := &information.Container.Properties
+	properties.Duration = uint64(mkv.Duration * float64(mkv.TimestampScale))
+	properties.Title = mkv.Title
+	properties.MuxingApplication = mkv.MuxingApp
+	properties.WritingApplication = mkv.WritingApp
+	if len(mkv.SegmentUID) > 0 {
+		properties.SegmentUID = hex.EncodeToString(mkv.SegmentUID)
+	}
+	if mkv.DateUTC != 0 {
+		// Matroska dates are nanoseconds since the beginning of the millennium
+		millennium := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+		properties.DateUTC = millennium.Add(time.Duration(mkv.DateUTC)).Format(time.RFC3339)
+	}
+
+	for _, count := range mkv.ChapterEditions {
+		information.Chapters = append(information.Chapters, entries{NumEntries: count})
+	}
+
+	if mkv.GlobalTags > 0 {
+		information.GlobalTags = []entries{{NumEntries: mkv.GlobalTags}}
+	}
+
+	// mkvmerge attachment IDs start at 1
+	for i, attachment := range mkv.Attachments {
+		information.Attachments = append(information.Attachments, Attachment{
+			ID:          uint(i + 1),
+			FileName:    attachment.Name,
+			ContentType: attachment.MimeType,
+			Description: attachment.Description,
+			Size:        attachment.Size,
+			Properties:  attachmentProps{UID: attachment.UID},
+		})
+	}
+
+	id := uint(0)
+	for _, mkvTrack := range mkv.Tracks {
+		track := &Track{
+			ID:    id,
+			Type:  mkvTrack.typeName(),
+			Codec: codecName(mkvTrack.CodecID),
+		}
+
+		// mkvmerge only identifies video, audio and subtitle tracks
+		if track.Type == "" {
+			continue
+		}
+		id++
+
+		track.Properties.CodecID = mkvTrack.CodecID
+		track.Properties.Language = mkvTrack.Language
+		track.Properties.LanguageIETF = mkvTrack.LanguageIETF
+		track.Properties.TrackName = mkvTrack.Name
+		track.Properties.Default = mkvTrack.Default
+		track.Properties.Forced = mkvTrack.Forced
+		track.Properties.HearingImpaired = mkvTrack.HearingImpaired
+		track.Properties.VisualImpaired = mkvTrack.VisualImpaired
+		track.Properties.Original = mkvTrack.Original
+		track.Properties.Commentary = mkvTrack.Commentary
+		track.Properties.DefaultDuration = mkvTrack.DefaultDuration
+		track.Properties.UID = mkvTrack.UID
+		track.Properties.Number = uint(mkvTrack.Number)
+
+		switch track.Type {
+		case "video":
+			track.Properties.PixelDimensions = fmt.Sprintf("%dx%d", mkvTrack.PixelWidth, mkvTrack.PixelHeight)
+			track.Properties.ColorBitsPerChannel = int(mkvTrack.BitsPerChannel)
+			track.Properties.ColorTransferCharacteristics = int(mkvTrack.TransferChars)
+			track.Properties.DolbyVision = mkvTrack.DolbyVision
+		case "audio":
+			track.Properties.AudioChannels = int(mkvTrack.Channels)
+			track.Properties.AudioSamplingFrequency = int(mkvTrack.SamplingFreq)
+			track.Properties.AudioBitsPerSample = int(mkvTrack.BitDepth)
+			track.Properties.AudioEmphasis = int(mkvTrack.Emphasis)
+		}
+
+		if len(mkvTrack.CodecPrivate) > 0 {
+			track.Properties.CodecPrivateData = hex.EncodeToString(mkvTrack.CodecPrivate)
+			track.Properties.CodecPrivateLength = len(mkvTrack.CodecPrivate)
+		}
+
+		if dimensions := mkvTrack.displayDimensions(); dimensions != "" {
+			track.Properties.Dimensions = &dimensions
+		}
+
+		track.Properties.TagBps = mkvTrack.StatisticsTags["BPS"]
+		track.Properties.TagDuration = mkvTrack.StatisticsTags["DURATION"]
+		track.Properties.TagNumberOfFrames = mkvTrack.StatisticsTags["NUMBER_OF_FRAMES"]
+		track.Properties.TagNumberOfBytes = mkvTrack.StatisticsTags["NUMBER_OF_BYTES"]
+
+		information.Tracks = append(information.Tracks, TrackController{Track: track})
+	}
+
+	return
+}
+
+func (track matroskaTrack) typeName() string {
+	switch track.Type {
+	case mkvTrackTypeVideo:
+		return "video"
+	case mkvTrackTypeAudio:
+		return "audio"
+	case mkvTrackTypeSubtitles:
+		return "subtitles"
+	}
+
+	return ""
+}
+
+func (track matroskaTrack) displayDimensions() string {
+	if track.Type != mkvTrackTypeVideo || track.PixelWidth == 0 || track.PixelHeight == 0 {
+		return ""
+	}
+
+	width, height := track.PixelWidth, track.PixelHeight
+	if track.DisplayWidth > 0 && track.DisplayHeight > 0 {
+		switch track.DisplayUnit {
+		// Pixels (the default unit)
+		case 0:
+			width, height = track.DisplayWidth, track.DisplayHeight
+		// Display aspect ratio
+		case 3:
+			width = height * track.DisplayWidth / track.DisplayHeight
+		}
+	}
+
+	return fmt.Sprintf("%dx%d", width, height)
+}
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func ebml(id uint32, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+
+	var element []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> uint(shift)); b != 0 || len(element) > 0 {
+			element = append(element, b)
+		}
+	}
+
+	// Always use 8 bytes sizes, as mkvmerge does for most of the elements
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(data)))
+	size[0] = 0x01
+
+	return append(append(element, size...), data...)
+}
+
+func ebmlUint(id uint32, value uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, value)
+
+	return ebml(id, data)
+}
+
+func ebmlFloat(id uint32, value float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(value))
+
+	return ebml(id, data)
+}
+
+func ebmlString(id uint32, value string) []byte {
+	return ebml(id, []byte(value))
+}
+
+func matroskaFixture() []byte {
+	return append(
+		ebml(mkvEBML, ebmlString(mkvDocType, "matroska")),
+		ebml(mkvSegment,
+			ebml(mkvInfo,
+				ebmlUint(mkvTimestampScale, 1000000),
+				ebmlFloat(mkvDuration, 5025000),
+				ebmlString(mkvTitle, "A title"),
+				ebmlString(mkvMuxingApp, "libebml v1.3.7 + libmatroska v1.5.0"),
+				ebmlString(mkvWritingApp, "mkvmerge v34.0.0 ('Sight and Seen') 64-bit"),
+				ebml(mkvSegmentUID, []byte{0xDE, 0xAD, 0xBE, 0xEF}),
+				ebmlUint(mkvDateUTC, 60*60*1000000000),
+			),
+			ebml(mkvTracks,
+				ebml(mkvTrackEntry,
+					ebmlUint(mkvTrackNumber, 1),
+					ebmlUint(mkvTrackUID, 111),
+					ebmlUint(mkvTrackType, mkvTrackTypeVideo),
+					ebmlUint(mkvDefaultDuration, 41708333),
+					ebmlString(mkvCodecID, "V_MPEGH/ISO/HEVC"),
+					ebml(mkvCodecPrivate, []byte{0x01, 0x02, 0xFF}),
+					ebml(mkvBlockAddMapping, ebmlUint(mkvBlockAddIDType, 0x64766343)),
+					ebml(mkvVideo,
+						ebmlUint(mkvPixelWidth, 1920),
+						ebmlUint(mkvPixelHeight, 800),
+						ebml(mkvColour,
+							ebmlUint(mkvBitsPerChannel, 10),
+							ebmlUint(mkvTransferChars, 16),
+						),
+					),
+				),
+				ebml(mkvTrackEntry,
+					ebmlUint(mkvTrackNumber, 2),
+					ebmlUint(mkvTrackUID, 222),
+					ebmlUint(mkvTrackType, mkvTrackTypeAudio),
+					ebmlUint(mkvFlagDefault, 0),
+					ebmlUint(mkvFlagCommentary, 1),
+					ebmlString(mkvName, "Director's commentary"),
+					ebmlString(mkvLanguage, "spa"),
+					ebmlString(mkvLanguageIETF, "es-ES"),
+					ebmlString(mkvCodecID, "A_AC3"),
+					ebml(mkvAudio,
+						ebmlFloat(mkvSamplingFreq, 48000),
+						ebmlUint(mkvChannels, 6),
+						ebmlUint(mkvBitDepth, 24),
+					),
+				),
+				// Button tracks are not identified by mkvmerge
+				ebml(mkvTrackEntry,
+					ebmlUint(mkvTrackNumber, 3),
+					ebmlUint(mkvTrackType, 0x12),
+				),
+				ebml(mkvTrackEntry,
+					ebmlUint(mkvTrackNumber, 4),
+					ebmlUint(mkvTrackUID, 444),
+					ebmlUint(mkvTrackType, mkvTrackTypeSubtitles),
+					ebmlUint(mkvFlagForced, 1),
+					ebmlUint(mkvFlagHearingImp, 1),
+					ebmlString(mkvCodecID, "S_TEXT/UTF8"),
+				),
+			),
+			// Clusters are never read
+			ebml(mkvCluster, bytes.Repeat([]byte{0xFF}, 64)),
+			ebml(mkvChapters,
+				ebml(mkvEditionEntry,
+					ebml(mkvChapterAtom),
+					ebml(mkvChapterAtom),
+				),
+			),
+			ebml(mkvTags,
+				ebml(mkvTag,
+					ebml(mkvTargets, ebmlUint(mkvTagTrackUID, 111)),
+					ebml(mkvSimpleTag, ebmlString(mkvTagName, "BPS"), ebmlString(mkvTagString, "12345678")),
+					ebml(mkvSimpleTag, ebmlString(mkvTagName, "NUMBER_OF_FRAMES"), ebmlString(mkvTagString, "120480")),
+				),
+				ebml(mkvTag,
+					ebml(mkvTargets),
+					ebml(mkvSimpleTag, ebmlString(mkvTagName, "ENCODER"), ebmlString(mkvTagString, "whatever")),
+				),
+			),
+			ebml(mkvAttachments,
+				ebml(mkvAttachedFile,
+					ebmlString(mkvFileName, "font.ttf"),
+					ebmlString(mkvFileMimeType, "font/ttf"),
+					ebml(mkvFileData, make([]byte, 32)),
+					ebmlUint(mkvFileUID, 999),
+				),
+			),
+		)...,
+	)
+}
+
+func TestReadMatroskaReadsAllMetadataElements(t *testing.T) {
+	mkv, err := readMatroska(bytes.NewReader(matroskaFixture()))
+
+	tests.Ok(t, err)
+	tests.Equals(t, "A title", mkv.Title)
+	tests.Equals(t, 4, len(mkv.Tracks))
+	tests.Equals(t, []int{2}, mkv.ChapterEditions)
+	tests.Equals(t, 1, mkv.GlobalTags)
+	tests.Equals(t, []matroskaAttachment{{UID: 999, Name: "font.ttf", MimeType: "font/ttf", Size: 32}}, mkv.Attachments)
+	tests.Equals(t, "12345678", mkv.Tracks[0].StatisticsTags["BPS"])
+}
+
+func TestReadMatroskaMapsToMkvmergeInfo(t *testing.T) {
+	mkv, err := readMatroska(bytes.NewReader(matroskaFixture()))
+	tests.Ok(t, err)
+
+	info := mkv.info()
+
+	tests.Equals(t, 5025*time.Second, info.GetDuration())
+	tests.Equals(t, "A title", info.Container.Properties.Title)
+	tests.Equals(t, "libebml v1.3.7 + libmatroska v1.5.0", info.Container.Properties.MuxingApplication)
+	tests.Equals(t, "mkvmerge v34.0.0 ('Sight and Seen') 64-bit", info.Container.Properties.WritingApplication)
+	tests.Equals(t, "deadbeef", info.Container.Properties.SegmentUID)
+	tests.Equals(t, "2001-01-01T01:00:00Z", info.Container.Properties.DateUTC)
+	tests.Equals(t, 1, info.GetChapterEditions())
+	tests.Equals(t, 1, info.GetGlobalTags())
+	tests.Equals(t, []Attachment{{
+		ID:          1,
+		FileName:    "font.ttf",
+		ContentType: "font/ttf",
+		Size:        32,
+		Properties:  attachmentProps{UID: 999},
+	}}, info.GetFonts())
+	tests.Equals(t, 3, len(info.Tracks))
+
+	video := info.Tracks[0].Track
+	tests.Equals(t, "video", video.Type)
+	tests.Equals(t, "MPEG-H/HEVC/h.265", video.Codec)
+	tests.Equals(t, "800", video.GetHeight())
+	tests.Equals(t, "eng", video.Properties.Language)
+	tests.Equals(t, true, video.Properties.Default)
+	tests.Equals(t, uint64(111), video.Properties.UID)
+	tests.Equals(t, "0102ff", video.Properties.CodecPrivateData)
+	tests.Equals(t, "12345678", video.Properties.TagBps)
+	tests.Equals(t, "120480", video.Properties.TagNumberOfFrames)
+	tests.Equals(t, "1920x800", video.Properties.PixelDimensions)
+	tests.Equals(t, uint64(41708333), video.Properties.DefaultDuration)
+	tests.Equals(t, uint(1), video.Properties.Number)
+	tests.Equals(t, 10, video.GetBitDepth())
+	tests.Equals(t, 16, video.Properties.ColorTransferCharacteristics)
+	tests.Equals(t, "Dolby Vision", video.GetHDR())
+
+	audio := info.Tracks[1].Track
+	tests.Equals(t, "1", audio.GetID())
+	tests.Equals(t, "AC-3", audio.Codec)
+	tests.Equals(t, "spa", audio.Properties.Language)
+	tests.Equals(t, false, audio.Properties.Default)
+	tests.Equals(t, true, audio.Properties.Commentary)
+	tests.Equals(t, "Director's commentary", audio.Properties.TrackName)
+	tests.Equals(t, "es-ES", audio.Properties.LanguageIETF)
+	tests.Equals(t, 6, audio.Properties.AudioChannels)
+	tests.Equals(t, 48000, audio.Properties.AudioSamplingFrequency)
+	tests.Equals(t, 24, audio.Properties.AudioBitsPerSample)
+	tests.Equals(t, uint(2), audio.Properties.Number)
+
+	subtitle := info.Tracks[2].Track
+	tests.Equals(t, "2", subtitle.GetID())
+	tests.Equals(t, "subtitles", subtitle.Type)
+	tests.Equals(t, true, subtitle.Properties.Forced)
+	tests.Equals(t, true, subtitle.Properties.HearingImpaired)
+	tests.Equals(t, uint(4), subtitle.Properties.Number)
+}
+
+func TestReadMatroskaFollowsSeekHeads(t *testing.T) {
+	tracks := ebml(mkvTracks,
+		ebml(mkvTrackEntry,
+			ebmlUint(mkvTrackNumber, 1),
+			ebmlUint(mkvTrackType, mkvTrackTypeAudio),
+			ebmlString(mkvCodecID, "A_OPUS"),
+		),
+	)
+	// Unknown sized clusters can't be skipped, so the tracks can only be found
+	// through the seek head.
+	cluster := append([]byte{0x1F, 0x43, 0xB6, 0x75, 0xFF}, bytes.Repeat([]byte{0xFF}, 16)...)
+
+	seekHead := func(position uint64) []byte {
+		return ebml(mkvSeekHead,
+			ebml(mkvSeek,
+				ebml(mkvSeekID, []byte{0x16, 0x54, 0xAE, 0x6B}),
+				ebmlUint(mkvSeekPosition, position),
+			),
+		)
+	}
+	position := uint64(len(seekHead(0)) + len(cluster))
+
+	file := append(
+		ebml(mkvEBML, ebmlString(mkvDocType, "webm")),
+		ebml(mkvSegment, seekHead(position), cluster, tracks)...,
+	)
+
+	mkv, err := readMatroska(bytes.NewReader(file))
+
+	tests.Ok(t, err)
+	tests.Equals(t, 1, len(mkv.Tracks))
+	tests.Equals(t, "A_OPUS", mkv.Tracks[0].CodecID)
+}
+
+func TestReadMatroskaRejectsOtherDocTypes(t *testing.T) {
+	_, err := readMatroska(bytes.NewReader(ebml(mkvEBML, ebmlString(mkvDocType, "other"))))
+
+	tests.Assert(t, err != nil, "expected an error for non Matroska files")
+}
+
+// hugeSize sets an 8 bytes EBML size way bigger than the element
+func hugeSize(element []byte, idLength int) []byte {
+	binary.BigEndian.PutUint64(element[idLength:], 1<<50)
+	element[idLength] = 0x01
+
+	return element
+}
+
+func TestReadMatroskaRejectsSizesBiggerThanTheFile(t *testing.T) {
+	header := ebml(mkvEBML, ebmlString(mkvDocType, "matroska"))
+
+	_, err := readMatroska(bytes.NewReader(hugeSize(header, 4)))
+	tests.Equals(t, errInvalidSize, err)
+
+	info := hugeSize(ebml(mkvInfo, ebmlUint(mkvTimestampScale, 1000000)), 4)
+	_, err = readMatroska(bytes.NewReader(append(header, ebml(mkvSegment, info)...)))
+	tests.Equals(t, errInvalidSize, err)
+}
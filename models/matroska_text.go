@@ -0,0 +1,149 @@
+package models
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// matroskaTextClusters is the maximum number of clusters read looking for text,
+// as sparse tracks (like forced subtitles) may never reach the limit and every
+// block header of the file would be read otherwise
+var matroskaTextClusters = 500
+
+// matroskaTextBlockLimit is the maximum size of a decompressed text block, as
+// a small compressed block could otherwise expand to gigabytes
+const matroskaTextBlockLimit = 64 * 1024
+
+// readMatroskaText returns the (decompressed) blocks of the given track
+// number, reading the clusters until limit bytes are found (or after reading
+// matroskaTextClusters clusters). Only the blocks headers are read for the
+// other tracks, which are skipped.
+func readMatroskaText(r io.ReadSeeker, number uint64, limit int) (blocks []string, err error) {
+	mkv, err := readMatroska(r)
+	if err != nil {
+		return
+	}
+
+	var track *matroskaTrack
+	for i := range mkv.Tracks {
+		if mkv.Tracks[i].Number == number {
+			track = &mkv.Tracks[i]
+		}
+	}
+	if track == nil {
+		return nil, fmt.Errorf("track number %d not found", number)
+	}
+
+	size := 0
+	pos := mkv.SegmentStart
+	// end of the current cluster, -1 when it has an unknown size
+	end := int64(-1)
+	inCluster := false
+	clusters := 0
+
+	for size < limit {
+		if inCluster && end >= 0 && pos >= end {
+			inCluster = false
+		}
+
+		if _, err = r.Seek(pos, io.SeekStart); err != nil {
+			return
+		}
+
+		header, err := readEBMLHeader(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return blocks, err
+		}
+
+		start := pos + header.HeaderSize
+		pos = start + header.Size
+
+		switch header.ID {
+		case mkvCluster:
+			if clusters == matroskaTextClusters {
+				return blocks, nil
+			}
+			clusters++
+			inCluster = true
+			end = pos
+			if header.Size == ebmlUnknownSize {
+				end = -1
+			}
+			// Its children are read one by one
+			pos = start
+		case mkvBlockGroup:
+			// Blocks are found inside block groups
+			if inCluster {
+				pos = start
+			}
+		case mkvSimpleBlock, mkvBlock:
+			if !inCluster {
+				continue
+			}
+
+			block, err := readMatroskaBlock(r, header.Size, track)
+			if err != nil {
+				return blocks, err
+			}
+			if block != "" {
+				blocks = append(blocks, block)
+				size += len(block)
+			}
+		default:
+			// Unknown sized elements (other than clusters) can't be skipped
+			if header.Size == ebmlUnknownSize {
+				return blocks, nil
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+// readMatroskaBlock returns the data of the block if it belongs to the given
+// track, and it's not laced (subtitles never are).
+func readMatroskaBlock(r io.ReadSeeker, size int64, track *matroskaTrack) (string, error) {
+	number, length, err := readVint(r, false)
+	if err != nil {
+		return "", err
+	}
+	if number != track.Number {
+		return "", nil
+	}
+
+	data, err := readEBMLData(r, size-int64(length))
+	if err != nil {
+		return "", err
+	}
+
+	// Timestamp (2 bytes) and flags
+	if len(data) < 3 || data[2]&0x06 != 0 {
+		return "", nil
+	}
+	data = data[3:]
+
+	switch track.Compression {
+	case mkvCompressionNone:
+	case mkvCompressionZlib:
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		// Anything past the limit is not a subtitle, so it's just dropped
+		if data, err = ioutil.ReadAll(io.LimitReader(reader, matroskaTextBlockLimit)); err != nil {
+			return "", err
+		}
+	case mkvCompressionHeaderStripping:
+		data = append(append([]byte{}, track.CompressionSets...), data...)
+	default:
+		return "", fmt.Errorf("unsupported compression algorithm %d", track.Compression)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,621 @@
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Matroska codec IDs for the MP4 sample entry types
+var mp4Codecs = map[string]string{
+	"av01": "V_AV1",
+	"hvc1": "V_MPEGH/ISO/HEVC",
+	"hev1": "V_MPEGH/ISO/HEVC",
+	"dvh1": "V_MPEGH/ISO/HEVC",
+	"dvhe": "V_MPEGH/ISO/HEVC",
+	"avc1": "V_MPEG4/ISO/AVC",
+	"avc3": "V_MPEG4/ISO/AVC",
+	"dva1": "V_MPEG4/ISO/AVC",
+	"mp4v": "V_MPEG4/ISO/ASP",
+	"vp08": "V_VP8",
+	"vp09": "V_VP9",
+	"mp4a": "A_AAC",
+	"ac-3": "A_AC3",
+	"ec-3": "A_EAC3",
+	"dtsc": "A_DTS",
+	"dtsh": "A_DTS",
+	"dtsl": "A_DTS",
+	"dtse": "A_DTS",
+	"mlpa": "A_TRUEHD",
+	"fLaC": "A_FLAC",
+	"Opus": "A_OPUS",
+	"alac": "A_ALAC",
+	".mp3": "A_MPEG/L3",
+	"sowt": "A_PCM/INT/LIT",
+	"twos": "A_PCM/INT/BIG",
+	"lpcm": "A_PCM/INT/LIT",
+	"ipcm": "A_PCM/INT/LIT",
+	"tx3g": "S_TEXT/UTF8",
+	"wvtt": "S_TEXT/WEBVTT",
+}
+
+// Matroska codec IDs for the MPEG-4 object type indications found in esds boxes
+var mp4ObjectTypes = map[byte]string{
+	0x20: "V_MPEG4/ISO/ASP",
+	0x21: "V_MPEG4/ISO/AVC",
+	0x40: "A_AAC",
+	0x60: "V_MPEG2",
+	0x61: "V_MPEG2",
+	0x66: "A_AAC",
+	0x67: "A_AAC",
+	0x68: "A_AAC",
+	0x69: "A_MPEG/L3",
+	0x6A: "V_MPEG1",
+	0x6B: "A_MPEG/L3",
+	0xA5: "A_AC3",
+	0xA6: "A_EAC3",
+	0xA9: "A_DTS",
+	0xAD: "A_OPUS",
+}
+
+// QuickTime (Macintosh) language codes, used by MOV files instead of ISO-639-2
+var quickTimeLanguages = []string{
+	"eng", "fre", "ger", "ita", "dut", "swe", "spa", "dan", "por", "nor",
+	"heb", "jpn", "ara", "fin", "gre", "ice", "mlt", "tur", "hrv", "chi",
+	"urd", "hin", "tha", "kor", "lit", "pol", "hun", "est", "lav", "smi",
+	"fao", "per", "rus", "chi", "dut", "gle", "alb", "rum", "cze", "slo",
+	"slv", "yid", "srp", "mac", "bul", "ukr", "bel", "uzb", "kaz", "aze",
+}
+
+type mp4Box struct {
+	Type string
+	Data []byte
+}
+
+type mp4Track struct {
+	ID            uint32
+	Enabled       bool
+	Handler       string
+	Name          string
+	Width         uint32
+	Height        uint32
+	Timescale     uint32
+	Duration      uint64
+	Language      string
+	LanguageIETF  string
+	SampleEntry   string
+	CodecID       string
+	PixelWidth    uint16
+	PixelHeight   uint16
+	Channels      uint16
+	SampleSize    uint16
+	SampleRate    uint32
+	SampleCount   uint32
+	SamplesLength uint64
+}
+
+type mp4File struct {
+	Timescale uint32
+	Duration  uint64
+	Tracks    []mp4Track
+}
+
+/*
+MP4Prober identifies ISO-BMFF (.mp4, .m4a, .m4v) and QuickTime (.mov) files
+natively, without spawning any external tool.
+*/
+type MP4Prober struct{}
+
+/*
+Probe the given MP4 file
+*/
+func (MP4Prober) Probe(file string) (information Info, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	mp4, err := readMP4(f)
+	if err != nil {
+		return
+	}
+
+	information = mp4.info()
+	information.FileName = file
+
+	fi, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	information.FileSize = fi.Size()
+
+	return information, nil
+}
+
+// errInvalidBoxSize is returned for boxes bigger than their file, whose data is
+// not allocated
+var errInvalidBoxSize = errors.New("MP4 box bigger than its file")
+
+// readMP4 looks for the moov box, skipping any other top level box.
+func readMP4(r io.ReadSeeker) (mp4 *mp4File, err error) {
+	header := make([]byte, 8)
+
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+
+	for pos := int64(0); ; {
+		if _, err = r.Seek(pos, io.SeekStart); err != nil {
+			return
+		}
+
+		if _, err = io.ReadFull(r, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = errUnsupportedContainer
+			}
+			return
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:])
+		headerSize := int64(8)
+
+		switch size {
+		// Box extends to the end of the file
+		case 0:
+			size = end - pos
+		// 64 bits sized box
+		case 1:
+			large := make([]byte, 8)
+			if _, err = io.ReadFull(r, large); err != nil {
+				return
+			}
+			size = int64(binary.BigEndian.Uint64(large))
+			headerSize += 8
+		}
+
+		if size < headerSize {
+			return nil, errUnsupportedContainer
+		}
+
+		if kind == "moov" {
+			if size > end-pos || size-headerSize > maxInt {
+				return nil, errInvalidBoxSize
+			}
+
+			data := make([]byte, size-headerSize)
+			if _, err = io.ReadFull(r, data); err != nil {
+				return
+			}
+
+			return parseMoov(data)
+		}
+
+		pos += size
+	}
+}
+
+// mp4Children splits the data of a container box into its children.
+func mp4Children(data []byte) (boxes []mp4Box) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		headerSize := uint64(8)
+		kind := string(data[4:8])
+
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			headerSize = 16
+		}
+
+		if size < headerSize || size > uint64(len(data)) {
+			return
+		}
+
+		boxes = append(boxes, mp4Box{Type: kind, Data: data[headerSize:size]})
+		data = data[size:]
+	}
+
+	return
+}
+
+func mp4Child(boxes []mp4Box, path ...string) (box mp4Box, ok bool) {
+	for _, box := range boxes {
+		if box.Type != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return box, true
+		}
+
+		return mp4Child(mp4Children(box.Data), path[1:]...)
+	}
+
+	return
+}
+
+func parseMoov(data []byte) (mp4 *mp4File, err error) {
+	mp4 = &mp4File{}
+	boxes := mp4Children(data)
+
+	if mvhd, ok := mp4Child(boxes, "mvhd"); ok {
+		mp4.Timescale, mp4.Duration = mp4TimescaleAndDuration(mvhd.Data, 12, 20)
+	}
+
+	for _, box := range boxes {
+		if box.Type == "trak" {
+			mp4.Tracks = append(mp4.Tracks, parseTrak(box.Data))
+		}
+	}
+
+	return
+}
+
+// mp4TimescaleAndDuration reads the timescale & duration of mvhd and mdhd
+// boxes, whose offsets depend on the box version.
+func mp4TimescaleAndDuration(data []byte, offset, offsetV1 int) (timescale uint32, duration uint64) {
+	if len(data) < 4 {
+		return
+	}
+
+	if data[0] == 1 {
+		if len(data) >= offsetV1+12 {
+			timescale = binary.BigEndian.Uint32(data[offsetV1:])
+			duration = binary.BigEndian.Uint64(data[offsetV1+4:])
+		}
+
+		return
+	}
+
+	if len(data) >= offset+8 {
+		timescale = binary.BigEndian.Uint32(data[offset:])
+		duration = uint64(binary.BigEndian.Uint32(data[offset+4:]))
+	}
+
+	return
+}
+
+func parseTrak(data []byte) (track mp4Track) {
+	boxes := mp4Children(data)
+
+	if tkhd, ok := mp4Child(boxes, "tkhd"); ok && len(tkhd.Data) >= 4 {
+		track.Enabled = tkhd.Data[3]&1 == 1
+
+		idOffset, sizeOffset := 12, 76
+		if tkhd.Data[0] == 1 {
+			idOffset, sizeOffset = 20, 88
+		}
+		if len(tkhd.Data) >= sizeOffset+8 {
+			track.ID = binary.BigEndian.Uint32(tkhd.Data[idOffset:])
+			// Fixed point 16.16 values
+			track.Width = binary.BigEndian.Uint32(tkhd.Data[sizeOffset:]) >> 16
+			track.Height = binary.BigEndian.Uint32(tkhd.Data[sizeOffset+4:]) >> 16
+		}
+	}
+
+	mdia, ok := mp4Child(boxes, "mdia")
+	if !ok {
+		return
+	}
+	boxes = mp4Children(mdia.Data)
+
+	if mdhd, ok := mp4Child(boxes, "mdhd"); ok {
+		track.Timescale, track.Duration = mp4TimescaleAndDuration(mdhd.Data, 12, 20)
+
+		offset := 20
+		if len(mdhd.Data) > 0 && mdhd.Data[0] == 1 {
+			offset = 32
+		}
+		if len(mdhd.Data) >= offset+2 {
+			track.Language = mp4Language(binary.BigEndian.Uint16(mdhd.Data[offset:]))
+		}
+	}
+
+	if hdlr, ok := mp4Child(boxes, "hdlr"); ok && len(hdlr.Data) >= 24 {
+		track.Handler = string(hdlr.Data[8:12])
+		track.Name = strings.TrimRight(string(hdlr.Data[24:]), "\x00")
+	}
+
+	if elng, ok := mp4Child(boxes, "elng"); ok && len(elng.Data) > 4 {
+		track.LanguageIETF = strings.TrimRight(string(elng.Data[4:]), "\x00")
+	}
+
+	if stbl, ok := mp4Child(boxes, "minf", "stbl"); ok {
+		stblBoxes := mp4Children(stbl.Data)
+
+		if stsd, ok := mp4Child(stblBoxes, "stsd"); ok && len(stsd.Data) > 8 {
+			if entries := mp4Children(stsd.Data[8:]); len(entries) > 0 {
+				track.sampleEntry(entries[0])
+			}
+		}
+
+		if stsz, ok := mp4Child(stblBoxes, "stsz"); ok {
+			track.sampleSizes(stsz.Data)
+		}
+	}
+
+	return
+}
+
+// mp4Language unpacks the ISO-639-2/T language code of mdhd boxes.
+func mp4Language(code uint16) string {
+	if code < 0x400 {
+		if int(code) < len(quickTimeLanguages) {
+			return quickTimeLanguages[code]
+		}
+
+		return "und"
+	}
+
+	if code == 0x7FFF {
+		return "und"
+	}
+
+	return string([]byte{
+		byte(code>>10&0x1F) + 0x60,
+		byte(code>>5&0x1F) + 0x60,
+		byte(code&0x1F) + 0x60,
+	})
+}
+
+func (track *mp4Track) sampleEntry(entry mp4Box) {
+	track.SampleEntry = entry.Type
+	track.CodecID = mp4Codecs[entry.Type]
+	data := entry.Data
+
+	switch track.Handler {
+	case "vide":
+		// reserved(6) + data reference index(2) + pre defined & reserved(16)
+		if len(data) < 78 {
+			return
+		}
+		track.PixelWidth = binary.BigEndian.Uint16(data[24:])
+		track.PixelHeight = binary.BigEndian.Uint16(data[26:])
+		data = data[78:]
+	case "soun":
+		if len(data) < 28 {
+			return
+		}
+		track.Channels = binary.BigEndian.Uint16(data[16:])
+		track.SampleSize = binary.BigEndian.Uint16(data[18:])
+		track.SampleRate = binary.BigEndian.Uint32(data[24:]) >> 16
+
+		// QuickTime sound sample descriptions are larger on v1 & v2
+		switch binary.BigEndian.Uint16(data[8:]) {
+		case 1:
+			if len(data) < 28+16 {
+				return
+			}
+			data = data[28+16:]
+		case 2:
+			if len(data) < 28+36 {
+				return
+			}
+			track.SampleRate = uint32(mp4Float64(data[32:]))
+			track.Channels = uint16(binary.BigEndian.Uint32(data[40:]))
+			track.SampleSize = uint16(binary.BigEndian.Uint32(data[48:]))
+			data = data[28+36:]
+		default:
+			data = data[28:]
+		}
+	default:
+		return
+	}
+
+	if esds, ok := mp4Child(mp4Children(data), "esds"); ok {
+		if codecID, ok := mp4ObjectTypes[mp4ObjectType(esds.Data)]; ok {
+			track.CodecID = codecID
+		}
+	}
+}
+
+func (track *mp4Track) sampleSizes(data []byte) {
+	if len(data) < 12 {
+		return
+	}
+
+	size := binary.BigEndian.Uint32(data[4:])
+	track.SampleCount = binary.BigEndian.Uint32(data[8:])
+
+	if size != 0 {
+		track.SamplesLength = uint64(size) * uint64(track.SampleCount)
+		return
+	}
+
+	for i := 12; i+4 <= len(data); i += 4 {
+		track.SamplesLength += uint64(binary.BigEndian.Uint32(data[i:]))
+	}
+}
+
+// mp4ObjectType extracts the object type indication of the decoder config
+// descriptor found inside an esds box.
+func mp4ObjectType(data []byte) byte {
+	// Skip version & flags
+	if len(data) < 4 {
+		return 0
+	}
+	data = data[4:]
+
+	for len(data) > 1 {
+		tag := data[0]
+		data = data[1:]
+
+		// Descriptor sizes are variable length
+		size := 0
+		for i := 0; i < 4 && len(data) > 0; i++ {
+			b := data[0]
+			data = data[1:]
+			size = size<<7 | int(b&0x7F)
+			if b&0x80 == 0 {
+				break
+			}
+		}
+
+		switch tag {
+		// ES descriptor, containing the decoder config one
+		case 0x03:
+			if len(data) < 3 {
+				return 0
+			}
+			flags := data[2]
+			skip := 3
+			if flags&0x80 != 0 {
+				skip += 2
+			}
+			if flags&0x40 != 0 && len(data) > skip {
+				skip += int(data[skip]) + 1
+			}
+			if flags&0x20 != 0 {
+				skip += 2
+			}
+			if len(data) < skip {
+				return 0
+			}
+			data = data[skip:]
+		// Decoder config descriptor
+		case 0x04:
+			if len(data) < 1 {
+				return 0
+			}
+			return data[0]
+		default:
+			if len(data) < size {
+				return 0
+			}
+			data = data[size:]
+		}
+	}
+
+	return 0
+}
+
+func mp4Float64(data []byte) float64 {
+	bits := binary.BigEndian.Uint64(data)
+
+	return math.Float64frombits(bits)
+}
+
+func (track mp4Track) typeName() string {
+	switch track.Handler {
+	case "vide":
+		return "video"
+	case "soun":
+		return "audio"
+	case "sbtl", "subt", "text":
+		return "subtitles"
+	}
+
+	return ""
+}
+
+func (track mp4Track) duration() time.Duration {
+	if track.Timescale == 0 {
+		return 0
+	}
+
+	return time.Duration(float64(track.Duration) / float64(track.Timescale) * float64(time.Second))
+}
+
+// info maps the MP4 data to the same structure mkvmerge's JSON identification
+// output produces.
+func (mp4 *mp4File) info() (information Info) {
+	information.Container.Recognized = true
+	information.Container.Supported = true
+	if mp4.Timescale > 0 {
+		information.Container.Properties.Duration = uint64(float64(mp4.Duration) / float64(mp4.Timescale) * float64(time.Second))
+	}
+
+	id := uint(0)
+	for _, mp4Track := range mp4.Tracks {
+		track := &Track{
+			ID:   id,
+			Type: mp4Track.typeName(),
+		}
+
+		// mkvmerge only identifies video, audio and subtitle tracks
+		if track.Type == "" {
+			continue
+		}
+		id++
+
+		track.Properties.CodecID = mp4Track.CodecID
+		if track.Properties.CodecID == "" {
+			track.Properties.CodecID = strings.ToUpper(strings.TrimSpace(mp4Track.SampleEntry))
+		}
+		track.Codec = codecName(track.Properties.CodecID)
+		// DTS-HD lossless streams have their own sample entry
+		if mp4Track.SampleEntry == "dtsl" {
+			track.Codec = "DTS-HD Master Audio"
+		}
+		track.Properties.Default = mp4Track.Enabled
+		track.Properties.UID = uint64(mp4Track.ID)
+		track.Properties.Number = uint(mp4Track.ID)
+
+		track.Properties.Language = mp4Track.Language
+		track.Properties.LanguageIETF = mp4Track.LanguageIETF
+		if track.Properties.Language == "und" && len(mp4Track.LanguageIETF) == 3 {
+			track.Properties.Language = mp4Track.LanguageIETF
+		}
+
+		switch track.Type {
+		case "video":
+			width, height := mp4Track.Width, mp4Track.Height
+			if width == 0 || height == 0 {
+				width, height = uint32(mp4Track.PixelWidth), uint32(mp4Track.PixelHeight)
+			}
+			if width > 0 && height > 0 {
+				dimensions := fmt.Sprintf("%dx%d", width, height)
+				track.Properties.Dimensions = &dimensions
+			}
+			if mp4Track.PixelWidth > 0 && mp4Track.PixelHeight > 0 {
+				track.Properties.PixelDimensions = fmt.Sprintf("%dx%d", mp4Track.PixelWidth, mp4Track.PixelHeight)
+			}
+			if mp4Track.SampleCount > 0 {
+				track.Properties.DefaultDuration = uint64(mp4Track.duration()) / uint64(mp4Track.SampleCount)
+			}
+		case "audio":
+			track.Properties.AudioChannels = int(mp4Track.Channels)
+			track.Properties.AudioSamplingFrequency = int(mp4Track.SampleRate)
+			track.Properties.AudioBitsPerSample = int(mp4Track.SampleSize)
+		}
+
+		// Statistics, as mkvmerge writes them as tags in Matroska files
+		if duration := mp4Track.duration(); duration > 0 && mp4Track.SampleCount > 0 {
+			track.Properties.TagDuration = formatTagDuration(duration)
+			track.Properties.TagNumberOfFrames = strconv.FormatUint(uint64(mp4Track.SampleCount), 10)
+			track.Properties.TagNumberOfBytes = strconv.FormatUint(mp4Track.SamplesLength, 10)
+			track.Properties.TagBps = strconv.FormatUint(uint64(float64(mp4Track.SamplesLength*8)/duration.Seconds()), 10)
+		}
+
+		information.Tracks = append(information.Tracks, TrackController{Track: track})
+	}
+
+	return
+}
+
+// formatTagDuration formats a duration the same way mkvmerge's DURATION
+// statistics tag does (HH:MM:SS.nnnnnnnnn).
+func formatTagDuration(duration time.Duration) string {
+	hours := duration / time.Hour
+	duration -= hours * time.Hour
+	minutes := duration / time.Minute
+	duration -= minutes * time.Minute
+	seconds := duration / time.Second
+	duration -= seconds * time.Second
+
+	return fmt.Sprintf("%02d:%02d:%02d.%09d", hours, minutes, seconds, duration)
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func box(kind string, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)+8))
+	copy(header[4:], kind)
+
+	return append(header, data...)
+}
+
+func be(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.BigEndian.PutUint32(data[i*4:], value)
+	}
+
+	return data
+}
+
+func tkhd(id, width, height uint32) []byte {
+	data := be(0x00000001, 0, 0, id, 0, 0, 0, 0, 0, 0)
+	data = append(data, make([]byte, 36)...)
+
+	return box("tkhd", data, be(width<<16, height<<16))
+}
+
+func mdhd(timescale, duration uint32, language string) []byte {
+	code := uint16(language[0]-0x60)<<10 | uint16(language[1]-0x60)<<5 | uint16(language[2]-0x60)
+	packed := make([]byte, 4)
+	binary.BigEndian.PutUint16(packed, code)
+
+	return box("mdhd", be(0, 0, 0, timescale, duration), packed)
+}
+
+func hdlr(handler, name string) []byte {
+	return box("hdlr", be(0, 0), []byte(handler), make([]byte, 12), []byte(name+"\x00"))
+}
+
+func stbl(entry []byte, sizes ...uint32) []byte {
+	return box("minf", box("stbl",
+		box("stsd", be(0, 1), entry),
+		box("stsz", be(0, 0, uint32(len(sizes))), be(sizes...)),
+	))
+}
+
+func mp4Fixture() []byte {
+	videoEntry := box("hvc1", make([]byte, 24), []byte{0x07, 0x80, 0x04, 0x38}, make([]byte, 50))
+	audioEntry := box("mp4a",
+		make([]byte, 16), []byte{0x00, 0x06, 0x00, 0x10}, make([]byte, 4), be(48000<<16),
+		box("esds", be(0), []byte{
+			0x03, 0x19, 0x00, 0x01, 0x00,
+			0x04, 0x11, 0xA6, 0x15, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		}),
+	)
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("isom"), be(0x200), []byte("isomiso2")),
+		box("mdat", make([]byte, 128)),
+		box("moov",
+			box("mvhd", be(0, 0, 0, 1000, 5400000), make([]byte, 80)),
+			box("trak",
+				tkhd(1, 1920, 800),
+				box("mdia", mdhd(24000, 240240, "und"), hdlr("vide", "VideoHandler"), stbl(videoEntry, 1000, 2000, 3000)),
+			),
+			box("trak",
+				tkhd(2, 0, 0),
+				box("mdia",
+					mdhd(48000, 480000, "spa"),
+					hdlr("soun", "SoundHandler"),
+					box("elng", be(0), []byte("es-419\x00")),
+					stbl(audioEntry, 10000, 10000),
+				),
+			),
+			box("trak",
+				tkhd(3, 0, 0),
+				box("mdia", mdhd(1000, 1000, "und"), hdlr("meta", ""), stbl(box("mett", make([]byte, 8)))),
+			),
+			box("trak",
+				tkhd(4, 0, 0),
+				box("mdia", mdhd(1000, 5400000, "eng"), hdlr("sbtl", ""), stbl(box("tx3g", make([]byte, 8)), 20)),
+			),
+		),
+	}, nil)
+}
+
+func TestReadMP4ReadsTheMoovBox(t *testing.T) {
+	mp4, err := readMP4(bytes.NewReader(mp4Fixture()))
+
+	tests.Ok(t, err)
+	tests.Equals(t, 4, len(mp4.Tracks))
+
+	video := mp4.Tracks[0]
+	tests.Equals(t, "hvc1", video.SampleEntry)
+	tests.Equals(t, uint16(1920), video.PixelWidth)
+	tests.Equals(t, uint16(1080), video.PixelHeight)
+	tests.Equals(t, uint32(3), video.SampleCount)
+	tests.Equals(t, uint64(6000), video.SamplesLength)
+
+	audio := mp4.Tracks[1]
+	tests.Equals(t, "A_EAC3", audio.CodecID)
+	tests.Equals(t, uint16(6), audio.Channels)
+	tests.Equals(t, uint32(48000), audio.SampleRate)
+	tests.Equals(t, "es-419", audio.LanguageIETF)
+}
+
+func TestReadMP4MapsToMkvmergeInfo(t *testing.T) {
+	mp4, err := readMP4(bytes.NewReader(mp4Fixture()))
+	tests.Ok(t, err)
+
+	info := mp4.info()
+
+	tests.Equals(t, 5400*time.Second, info.GetDuration())
+	tests.Equals(t, 3, len(info.Tracks))
+
+	video := info.Tracks[0].Track
+	tests.Equals(t, "video", video.Type)
+	tests.Equals(t, "MPEG-H/HEVC/h.265", video.Codec)
+	tests.Equals(t, "V_MPEGH/ISO/HEVC", video.Properties.CodecID)
+	tests.Equals(t, "800", video.GetHeight())
+	tests.Equals(t, "und", video.Properties.Language)
+	tests.Equals(t, true, video.Properties.Default)
+	tests.Equals(t, "00:00:10.010000000", video.Properties.TagDuration)
+	tests.Equals(t, "3", video.Properties.TagNumberOfFrames)
+	tests.Equals(t, "1920x1080", video.Properties.PixelDimensions)
+	tests.Equals(t, uint64(3336666666), video.Properties.DefaultDuration)
+
+	audio := info.Tracks[1].Track
+	tests.Equals(t, "1", audio.GetID())
+	tests.Equals(t, "E-AC-3", audio.Codec)
+	tests.Equals(t, "spa", audio.Properties.Language)
+	tests.Equals(t, "16000", audio.Properties.TagBps)
+	tests.Equals(t, "es-419", audio.Properties.LanguageIETF)
+	tests.Equals(t, 6, audio.Properties.AudioChannels)
+	tests.Equals(t, 48000, audio.Properties.AudioSamplingFrequency)
+	tests.Equals(t, 16, audio.Properties.AudioBitsPerSample)
+
+	subtitle := info.Tracks[2].Track
+	tests.Equals(t, "2", subtitle.GetID())
+	tests.Equals(t, "subtitles", subtitle.Type)
+	tests.Equals(t, "S_TEXT/UTF8", subtitle.Properties.CodecID)
+	tests.Equals(t, "eng", subtitle.Properties.Language)
+}
+
+func TestMP4LanguageUnpacksQuickTimeAndISOCodes(t *testing.T) {
+	tests.Equals(t, "eng", mp4Language(0))
+	tests.Equals(t, "spa", mp4Language(6))
+	tests.Equals(t, "und", mp4Language(0x7FFF))
+	tests.Equals(t, "und", mp4Language(0x55C4))
+	tests.Equals(t, "fra", mp4Language(0x1A41))
+}
+
+func TestReadMP4FailsWithoutMoov(t *testing.T) {
+	_, err := readMP4(bytes.NewReader(box("ftyp", []byte("isom"))))
+
+	tests.Assert(t, err != nil, "expected an error when no moov box is found")
+}
+
+func TestReadMP4RejectsBoxesBiggerThanTheFile(t *testing.T) {
+	moov := box("moov", box("mvhd", make([]byte, 100)))
+	binary.BigEndian.PutUint32(moov, 0xFFFFFFF0)
+
+	_, err := readMP4(bytes.NewReader(append(box("ftyp", []byte("isom")), moov...)))
+
+	tests.Equals(t, errInvalidBoxSize, err)
+}
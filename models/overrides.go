@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+/*
+LanguageOverride sets the language of a track, identified by its input (either
+its file name or its 1-based position) and its track ID
+*/
+type LanguageOverride struct {
+	Input    string
+	Position int
+	Track    uint
+	Language string
+}
+
+func (override LanguageOverride) String() string {
+	input := override.Input
+	if input == "" {
+		input = fmt.Sprint(override.Position)
+	}
+
+	return fmt.Sprintf("%s:%d=%s", input, override.Track, override.Language)
+}
+
+/*
+TrackNotFoundError is returned when a language override refers to a track that
+does not exist
+*/
+type TrackNotFoundError struct {
+	Override LanguageOverride
+}
+
+func (err *TrackNotFoundError) Error() string {
+	return fmt.Sprintf("%s: track not found", err.Override)
+}
+
+/*
+ApplyLanguageOverrides sets the languages of the given overrides to their
+tracks, so they're used by the selection functions and set to the output.
+*/
+func (controller TracksController) ApplyLanguageOverrides(overrides []LanguageOverride) error {
+	for _, override := range overrides {
+		track := controller.findTrack(override)
+		if track == nil {
+			return &TrackNotFoundError{Override: override}
+		}
+
+		track.SetLanguage(override.Language)
+	}
+
+	return nil
+}
+
+/*
+SetLanguage changes the track language to the given code or BCP-47 tag
+*/
+func (track *Track) SetLanguage(language string) {
+	track.Properties.Language = NormalizeLanguage(language)
+	track.Properties.LanguageIETF = ""
+	if _, subtags := splitLanguageTag(language); subtags != "" {
+		track.Properties.LanguageIETF = language
+	}
+	track.Inference = nil
+}
+
+func (controller TracksController) findTrack(override LanguageOverride) *Track {
+	for _, input := range controller.Inputs {
+		if override.Input != "" && !SameFile(input.FileName, override.Input) {
+			continue
+		}
+		if override.Input == "" && input.Position != override.Position-1 {
+			continue
+		}
+
+		for _, track := range input.Tracks {
+			if track.Track.ID == override.Track {
+				return track.Track
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+SameFile tells whether both paths refer to the same file, either by their name
+or, when they exist, by their device and inode
+*/
+func SameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+
+	fa, erra := os.Stat(a)
+	fb, errb := os.Stat(b)
+
+	return erra == nil && errb == nil && os.SameFile(fa, fb)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestApplyLanguageOverrides(t *testing.T) {
+	first := &Info{FileName: "input1.mkv", Position: 0}
+	second := &Info{FileName: "input2.mkv", Position: 1}
+	mislabeled := TrackController{Input: first, Track: &Track{ID: 1, Type: "audio", Properties: properties{Language: "eng", LanguageIETF: "en-US"}}}
+	undefined := TrackController{Input: second, Track: &Track{ID: 1, Type: "audio", Properties: properties{Language: "und"}}}
+	first.Tracks = Tracks{mislabeled}
+	second.Tracks = Tracks{undefined}
+
+	controller := TracksController{Inputs: []*Info{first, second}, Audios: Tracks{mislabeled, undefined}}
+	err := controller.ApplyLanguageOverrides([]LanguageOverride{
+		{Input: "./input1.mkv", Track: 1, Language: "es"},
+		{Position: 2, Track: 1, Language: "es-419"},
+	})
+
+	tests.Ok(t, err)
+	tests.Equals(t, "spa", mislabeled.Track.GetLanguage())
+	tests.Equals(t, "es-419", undefined.Track.GetLanguage())
+
+	audios, err := controller.GetBestAudios([]string{"es-419"})
+	tests.Ok(t, err)
+	tests.Equals(t, Tracks{undefined}, audios)
+
+	err = controller.ApplyLanguageOverrides([]LanguageOverride{{Position: 3, Track: 1, Language: "spa"}})
+	tests.Equals(t, &TrackNotFoundError{Override: LanguageOverride{Position: 3, Track: 1, Language: "spa"}}, err)
+	tests.Equals(t, "3:1=spa: track not found", err.Error())
+}
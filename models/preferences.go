@@ -0,0 +1,60 @@
+package models
+
+/*
+Preferences configures how the best tracks are chosen. Nil values use the
+defaults.
+*/
+type Preferences struct {
+	VideoWeights *VideoWeights
+	// Audio codecs, from the most to the least preferred (see AudioPresets)
+	AudioCodecs []string
+	// Audio roles taken for each language (see AudioRoles)
+	AudioSet []string
+	// Subtitle formats, from the most to the least preferred
+	SubtitleFormats []string
+	// Keep just the best track of each language and kind when no languages
+	// are given
+	Dedupe bool
+	// Optional track kinds taken along with the main ones (see OptionalKinds)
+	IncludeKinds []string
+}
+
+func (preferences Preferences) videoWeights() VideoWeights {
+	if preferences.VideoWeights == nil {
+		return DefaultVideoWeights
+	}
+
+	return *preferences.VideoWeights
+}
+
+func (preferences Preferences) audioCodecs() []string {
+	if preferences.AudioCodecs == nil {
+		return AudioPresets["default"]
+	}
+
+	return preferences.AudioCodecs
+}
+
+// audioSet returns the audio roles, including the optional audio kinds
+func (preferences Preferences) audioSet() []string {
+	roles := preferences.AudioSet
+	if roles == nil {
+		roles = []string{"main"}
+	}
+
+	for _, kind := range preferences.IncludeKinds {
+		if containsString(AudioRoles, kind) && !containsString(roles, kind) {
+			roles = append(roles[:len(roles):len(roles)], kind)
+		}
+	}
+
+	return roles
+}
+
+func (preferences Preferences) subtitleFormats() []string {
+	if preferences.SubtitleFormats == nil {
+		return DefaultSubtitleFormats
+	}
+
+	return preferences.SubtitleFormats
+}
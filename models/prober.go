@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Prober identifies a file, returning its container and tracks information.
+*/
+type Prober interface {
+	Probe(file string) (Info, error)
+}
+
+// proberVersionCommands print the version of the tools used by the probers
+var proberVersionCommands = map[string][]string{
+	"mkvmerge": {"mkvmerge", "--version"},
+	"ffprobe":  {"ffprobe", "-version"},
+}
+
+/*
+ProberVersion returns the version of the given prober, which includes the
+version of the external tool it runs (if any), so cached results are discarded
+when the tool is upgraded.
+*/
+func ProberVersion(name string) string {
+	command, ok := proberVersionCommands[name]
+	if !ok {
+		return name
+	}
+
+	// The probe will fail anyway if the tool can't be run
+	output, err := exec.Command(command[0], command[1:]...).Output()
+	if err != nil {
+		return name
+	}
+
+	// Just the first line, like "mkvmerge v80.0 ('Roundabout') 64-bit"
+	line := strings.SplitN(strings.TrimSpace(string(output)), "\n", 2)[0]
+
+	return name + ":" + strings.TrimSpace(line)
+}
+
+/*
+MkvmergeProber identifies files using mkvmerge (the default prober).
+*/
+type MkvmergeProber struct{}
+
+/*
+Probe the given file using mkvmerge
+*/
+func (MkvmergeProber) Probe(file string) (Info, error) {
+	return GetFileInfo(file)
+}
+
+/*
+NativeProber identifies files without any external tool, choosing the reader
+based on the file extension.
+*/
+type NativeProber struct{}
+
+/*
+Probe the given file with the native reader for its container
+*/
+func (NativeProber) Probe(file string) (Info, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".mkv", ".mka", ".mks", ".mk3d", ".webm":
+		return MatroskaProber{}.Probe(file)
+	case ".mp4", ".m4a", ".m4v", ".mov", ".3gp":
+		return MP4Prober{}.Probe(file)
+	}
+
+	return Info{}, errUnsupportedContainer
+}
+
+/*
+FixtureProber returns previously recorded information instead of probing the
+files, which allows using the selection logic without real media files.
+
+Fixtures are first looked up by file name in the Fixtures map and, if not
+found there, loaded from a "{basename}.json" file in Dir containing the output
+of `mkvmerge -F json -i {file}`.
+*/
+type FixtureProber struct {
+	Dir      string
+	Fixtures map[string]Info
+}
+
+/*
+Probe returns the fixture for the given file
+*/
+func (prober FixtureProber) Probe(file string) (information Info, err error) {
+	if fixture, ok := prober.Fixtures[file]; ok {
+		information = fixture.clone()
+		information.FileName = file
+
+		return
+	}
+
+	if prober.Dir == "" {
+		return information, fmt.Errorf("no fixture found for %s", file)
+	}
+
+	output, err := ioutil.ReadFile(filepath.Join(prober.Dir, filepath.Base(file)+".json"))
+	if err != nil {
+		return
+	}
+
+	if information, err = parseMkvmergeJSON(output); err != nil {
+		return
+	}
+
+	information.FileName = file
+
+	return
+}
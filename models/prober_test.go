@@ -0,0 +1,244 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestProberVersionIncludesTheToolVersion(t *testing.T) {
+	commands := proberVersionCommands
+	defer func() { proberVersionCommands = commands }()
+
+	proberVersionCommands = map[string][]string{
+		"mkvmerge": {"echo", "mkvmerge v80.0 ('Roundabout') 64-bit"},
+		"ffprobe":  {"remuxing-missing-tool", "-version"},
+	}
+
+	tests.Equals(t, "mkvmerge:mkvmerge v80.0 ('Roundabout') 64-bit", ProberVersion("mkvmerge"))
+	tests.Equals(t, "ffprobe", ProberVersion("ffprobe"))
+	tests.Equals(t, "native", ProberVersion("native"))
+}
+
+func TestFixtureProberLoadsMkvmergeJSONFromDir(t *testing.T) {
+	info, err := FixtureProber{Dir: "testdata"}.Probe("/some/where/movie.mkv")
+
+	tests.Ok(t, err)
+	tests.Equals(t, "/some/where/movie.mkv", info.FileName)
+	tests.Equals(t, 5400*time.Second, info.GetDuration())
+	tests.Equals(t, "Movie", info.Container.Properties.Title)
+	tests.Equals(t, "2019-05-01T10:00:00Z", info.Container.Properties.DateUTC)
+	tests.Equals(t, 2, info.GetChapterEditions())
+	tests.Equals(t, 7, info.GetGlobalTags())
+	tests.Equals(t, 2, len(info.Attachments))
+	tests.Equals(t, 1, len(info.GetFonts()))
+	tests.Equals(t, "cover.jpg", info.Attachments[1].FileName)
+	tests.Equals(t, 3, len(info.Tracks))
+	tests.Equals(t, "A_AAC", info.Tracks[1].Track.Properties.CodecID)
+	tests.Equals(t, true, info.Tracks[2].Track.Properties.Forced)
+
+	audio := info.Tracks[1].Track
+	tests.Equals(t, "Original", audio.Properties.TrackName)
+	tests.Equals(t, 6, audio.Properties.AudioChannels)
+	tests.Equals(t, 48000, audio.Properties.AudioSamplingFrequency)
+	tests.Equals(t, "en-US", audio.Properties.LanguageIETF)
+	tests.Equals(t, true, audio.Properties.Original)
+	tests.Equals(t, uint64(1), audio.Properties.UID)
+	tests.Equals(t, uint(2), audio.Properties.Number)
+	tests.Equals(t, uint64(640000), audio.GetBitrate())
+	tests.Equals(t, 100*time.Minute+10*time.Second+500*time.Millisecond, audio.GetDuration())
+	tests.Equals(t, uint64(281904), audio.GetFrames())
+	tests.Equals(t, uint64(481536000), audio.GetBytes())
+}
+
+func TestFixtureProberFailsForUnknownFiles(t *testing.T) {
+	_, err := FixtureProber{}.Probe("unknown.mkv")
+
+	tests.Assert(t, err != nil, "expected an error for a missing fixture")
+}
+
+func TestBuildTracksUsesTheGivenProber(t *testing.T) {
+	dimensions := "1280x720"
+	prober := FixtureProber{
+		Dir: "testdata",
+		Fixtures: map[string]Info{
+			"small.mkv": {
+				Tracks: Tracks{
+					TrackController{Track: &Track{
+						ID:    0,
+						Type:  "video",
+						Codec: "MPEG-H/HEVC/h.265",
+						Properties: properties{
+							Dimensions: &dimensions,
+						},
+					}},
+					TrackController{Track: &Track{
+						ID:   1,
+						Type: "audio",
+						Properties: properties{
+							Language: "spa",
+							CodecID:  "A_AC3",
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	tracks, err := BuildTracks(prober, []string{"small.mkv", "movie.mkv"}, 2)
+
+	tests.Ok(t, err)
+
+	tests.Equals(t, 2, len(tracks.Videos))
+	tests.Equals(t, 2, len(tracks.Audios))
+	tests.Equals(t, 1, len(tracks.Subtitles))
+	video, err := tracks.GetBestVideo()
+	tests.Ok(t, err)
+	tests.Equals(t, "movie.mkv", video.Input.FileName)
+
+	audio, err := tracks.GetBestAudio("spa")
+	tests.Ok(t, err)
+	tests.Equals(t, "small.mkv", audio.Input.FileName)
+	tests.Equals(t, 1, tracks.Subtitles[0].Input.Position)
+}
+
+func TestBuildTracksKeepsInputsOrderWhenProbingConcurrently(t *testing.T) {
+	prober := FixtureProber{Fixtures: map[string]Info{}}
+	var inputs []string
+	for i := 0; i < 20; i++ {
+		input := fmt.Sprintf("input%d.mka", i)
+		inputs = append(inputs, input)
+		prober.Fixtures[input] = Info{
+			Tracks: Tracks{TrackController{Track: &Track{Type: "audio"}}},
+		}
+	}
+
+	tracks, err := BuildTracks(prober, inputs, 4)
+
+	tests.Ok(t, err)
+	tests.Equals(t, 20, len(tracks.Audios))
+	for pos, audio := range tracks.Audios {
+		tests.Equals(t, pos, audio.Input.Position)
+		tests.Equals(t, inputs[pos], audio.Input.FileName)
+	}
+}
+
+func TestBuildTracksReportsEveryFailingInput(t *testing.T) {
+	prober := FixtureProber{Dir: "testdata"}
+
+	_, err := BuildTracks(prober, []string{"missing.mkv", "movie.mkv", "other.mkv"}, 0)
+
+	errs, ok := err.(InputErrors)
+	tests.Assert(t, ok, "expected InputErrors, got %#v", err)
+	tests.Equals(t, 2, len(errs))
+	tests.Equals(t, &InputNotFoundError{Input: "missing.mkv", Position: 0}, errs[0])
+	tests.Equals(t, &InputNotFoundError{Input: "other.mkv", Position: 2}, errs[1])
+}
+
+type failingProber struct{}
+
+func (failingProber) Probe(file string) (Info, error) {
+	return Info{}, errors.New("boom")
+}
+
+func TestBuildTracksTypesProberErrors(t *testing.T) {
+	_, err := BuildTracks(failingProber{}, []string{"movie.mkv"}, 1)
+
+	tests.Equals(t, InputErrors{&ProbeError{Input: "movie.mkv", Err: errors.New("boom")}}, err)
+
+	_, err = BuildTracks(NativeProber{}, []string{"testdata/movie.mkv.json"}, 1)
+
+	tests.Equals(t, InputErrors{&UnsupportedContainerError{Input: "testdata/movie.mkv.json"}}, err)
+}
+
+func TestParseMkvmergeJSONRejectsUnsupportedContainers(t *testing.T) {
+	_, err := parseMkvmergeJSON([]byte(`{"container": {"recognized": true, "supported": false}}`))
+
+	tests.Equals(t, errUnsupportedContainer, err)
+}
+
+func TestMkvmergeErrorsReportsUnrecognizedFilesAsUnsupported(t *testing.T) {
+	// mkvmerge -J notes.txt
+	err := mkvmergeErrors([]byte(`{
+		"container": {"recognized": false, "supported": false},
+		"errors": ["The type of file 'notes.txt' could not be recognized."],
+		"file_name": "notes.txt",
+		"identification_format_version": 12,
+		"warnings": []
+	}`))
+
+	tests.Equals(t, errUnsupportedContainer, err)
+
+	err = mkvmergeErrors([]byte(`{
+		"container": {"recognized": true, "supported": true},
+		"errors": ["Error in the Matroska file structure at position 1234."]
+	}`))
+
+	tests.Equals(t, errors.New("Error in the Matroska file structure at position 1234."), err)
+}
+
+func TestFFprobeErrorTellsUnrecognizedFilesApart(t *testing.T) {
+	tests.Equals(t, errUnsupportedContainer, ffprobeError([]byte("notes.txt: Invalid data found when processing input\n")))
+	tests.Equals(t, errors.New("ffprobe failed: movie.mkv: Permission denied"), ffprobeError([]byte("movie.mkv: Permission denied\n")))
+}
+
+func TestParseFFprobeJSONMapsStreamsToTracks(t *testing.T) {
+	info, err := parseFFprobeJSON([]byte(`{
+		"streams": [
+			{"index": 0, "codec_name": "h264", "codec_type": "video", "width": 1920, "height": 1080, "sample_aspect_ratio": "1:1", "r_frame_rate": "24000/1001", "pix_fmt": "yuv420p10le", "color_transfer": "arib-std-b67", "disposition": {"default": 1}},
+			{"index": 1, "codec_name": "eac3", "codec_type": "audio", "channels": 6, "sample_rate": "48000", "disposition": {"comment": 1}, "tags": {"language": "spa", "title": "Comentarios", "BPS": "640000"}},
+			{"index": 2, "codec_name": "bin_data", "codec_type": "data", "tags": {"handler_name": "TimeCodeHandler"}},
+			{"index": 3, "codec_name": "subrip", "codec_type": "subtitle", "disposition": {"forced": 1}, "tags": {"language": "eng"}},
+			{"index": 4, "codec_name": "ttf", "codec_type": "attachment", "tags": {"filename": "Arial.ttf", "mimetype": "application/x-truetype-font"}}
+		],
+		"chapters": [{"id": 1}, {"id": 2}],
+		"format": {"duration": "1425.312000", "tags": {"title": "Episode 1", "ENCODER": "Lavf58.20.100"}}
+	}`))
+
+	tests.Ok(t, err)
+	tests.Equals(t, 1425312*time.Millisecond, info.GetDuration())
+	tests.Equals(t, "Episode 1", info.Container.Properties.Title)
+	tests.Equals(t, "Lavf58.20.100", info.Container.Properties.WritingApplication)
+	tests.Equals(t, 1, info.GetChapterEditions())
+	tests.Equals(t, []Attachment{{ID: 1, FileName: "Arial.ttf", ContentType: "application/x-truetype-font"}}, info.GetFonts())
+	tests.Equals(t, 3, len(info.Tracks))
+
+	video := info.Tracks[0].Track
+	tests.Equals(t, "video", video.Type)
+	tests.Equals(t, "MPEG-4p10/AVC/h.264", video.Codec)
+	tests.Equals(t, "V_MPEG4/ISO/AVC", video.Properties.CodecID)
+	tests.Equals(t, "1080", video.GetHeight())
+	tests.Equals(t, "und", video.Properties.Language)
+	tests.Equals(t, true, video.Properties.Default)
+	tests.Equals(t, "1920x1080", video.Properties.PixelDimensions)
+	tests.Equals(t, uint64(41708333), video.Properties.DefaultDuration)
+	tests.Equals(t, 10, video.GetBitDepth())
+	tests.Equals(t, "HLG", video.GetHDR())
+
+	audio := info.Tracks[1].Track
+	tests.Equals(t, "audio", audio.Type)
+	tests.Equals(t, "A_EAC3", audio.Properties.CodecID)
+	tests.Equals(t, "spa", audio.Properties.Language)
+	tests.Equals(t, "Comentarios", audio.Properties.TrackName)
+	tests.Equals(t, true, audio.Properties.Commentary)
+	tests.Equals(t, 6, audio.Properties.AudioChannels)
+	tests.Equals(t, 48000, audio.Properties.AudioSamplingFrequency)
+	tests.Equals(t, uint64(640000), audio.GetBitrate())
+
+	subtitle := info.Tracks[2].Track
+	tests.Equals(t, "subtitles", subtitle.Type)
+	// The data stream is not counted
+	tests.Equals(t, uint(2), subtitle.ID)
+	tests.Equals(t, uint(0), subtitle.Properties.Number)
+	tests.Equals(t, "S_TEXT/UTF8", subtitle.Properties.CodecID)
+	tests.Equals(t, true, subtitle.Properties.Forced)
+}
+
+func TestDisplayWidthAppliesSampleAspectRatio(t *testing.T) {
+	tests.Equals(t, 1920, displayWidth(1920, "1:1"))
+	tests.Equals(t, 1024, displayWidth(720, "64:45"))
+	tests.Equals(t, 720, displayWidth(720, ""))
+}
@@ -0,0 +1,136 @@
+package models
+
+// Sample texts used to build the trigram profile of each language detected by
+// the subtitles text classifier. They are written as dialogues, as that's what
+// subtitles usually contain.
+var languageSamples = map[string]string{
+	"eng": `What are you doing here? I thought you were going to stay at home tonight.
+I couldn't sleep, so I came to see if you needed any help with the boat.
+You know I don't like it when you walk alone through the woods at night.
+Don't worry about me. Where is your father? He said he would be back before dinner.
+He went to town with your brother. They should have been here an hour ago.
+Something is wrong, I can feel it. We have to find them before the storm comes.
+Listen to me, we are not going anywhere until the rain stops. Is that clear?
+I'm sorry, I didn't mean to shout. It has been a very long day for all of us.
+Thank you for everything you have done for this family. We would be lost without you.
+Let's have some tea and wait for them. Everything will be fine, I promise.
+Who was that man at the door this morning? He wanted to talk with your mother.
+I have never seen him before. He said that he would come back tomorrow.`,
+
+	"spa": `¿Qué estás haciendo aquí? Pensaba que esta noche te ibas a quedar en casa.
+No podía dormir, así que he venido a ver si necesitabas ayuda con el barco.
+Ya sabes que no me gusta que camines solo por el bosque de noche.
+No te preocupes por mí. ¿Dónde está tu padre? Dijo que volvería antes de la cena.
+Se ha ido al pueblo con tu hermano. Deberían haber llegado hace una hora.
+Algo va mal, lo noto. Tenemos que encontrarlos antes de que llegue la tormenta.
+Escúchame, no vamos a ninguna parte hasta que deje de llover. ¿Está claro?
+Lo siento, no quería gritar. Ha sido un día muy largo para todos nosotros.
+Gracias por todo lo que has hecho por esta familia. Sin ti estaríamos perdidos.
+Vamos a tomar un té y a esperarlos. Todo irá bien, te lo prometo.
+¿Quién era ese hombre que estaba en la puerta esta mañana? Quería hablar con tu madre.
+No lo había visto nunca. Dijo que volvería mañana por la tarde.`,
+
+	"cat": `Què estàs fent aquí? Pensava que aquesta nit et quedaries a casa.
+No podia dormir, així que he vingut a veure si necessitaves ajuda amb la barca.
+Ja saps que no m'agrada que caminis sol pel bosc de nit.
+No pateixis per mi. On és el teu pare? Va dir que tornaria abans del sopar.
+Ha anat al poble amb el teu germà. Haurien d'haver arribat fa una hora.
+Alguna cosa no va bé, ho noto. Els hem de trobar abans que arribi la tempesta.
+Escolta'm, no anirem enlloc fins que no deixi de ploure. Està clar?
+Ho sento, no volia cridar. Ha estat un dia molt llarg per a tots nosaltres.
+Gràcies per tot el que has fet per aquesta família. Sense tu estaríem perduts.
+Prenem un te i els esperem. Tot anirà bé, t'ho prometo.
+Qui era aquell home que era a la porta aquest matí? Volia parlar amb la teva mare.
+No l'havia vist mai. Va dir que tornaria demà a la tarda.`,
+
+	"fre": `Qu'est-ce que tu fais ici ? Je pensais que tu allais rester à la maison ce soir.
+Je n'arrivais pas à dormir, alors je suis venu voir si tu avais besoin d'aide avec le bateau.
+Tu sais que je n'aime pas que tu marches seul dans les bois la nuit.
+Ne t'inquiète pas pour moi. Où est ton père ? Il a dit qu'il rentrerait avant le dîner.
+Il est allé en ville avec ton frère. Ils auraient dû arriver il y a une heure.
+Quelque chose ne va pas, je le sens. Nous devons les trouver avant que la tempête arrive.
+Écoute-moi, nous n'irons nulle part tant que la pluie ne s'arrête pas. C'est clair ?
+Je suis désolé, je ne voulais pas crier. Ça a été une très longue journée pour nous tous.
+Merci pour tout ce que tu as fait pour cette famille. Sans toi nous serions perdus.
+Prenons un thé et attendons-les. Tout ira bien, je te le promets.
+Qui était cet homme à la porte ce matin ? Il voulait parler à ta mère.
+Je ne l'avais jamais vu. Il a dit qu'il reviendrait demain après-midi.`,
+
+	"ger": `Was machst du hier? Ich dachte, du wolltest heute Abend zu Hause bleiben.
+Ich konnte nicht schlafen, also bin ich gekommen, um zu sehen, ob du Hilfe mit dem Boot brauchst.
+Du weißt, dass ich es nicht mag, wenn du nachts allein durch den Wald läufst.
+Mach dir keine Sorgen um mich. Wo ist dein Vater? Er sagte, er wäre vor dem Abendessen zurück.
+Er ist mit deinem Bruder in die Stadt gefahren. Sie hätten vor einer Stunde hier sein sollen.
+Irgendetwas stimmt nicht, ich spüre es. Wir müssen sie finden, bevor der Sturm kommt.
+Hör mir zu, wir gehen nirgendwohin, bis der Regen aufhört. Ist das klar?
+Es tut mir leid, ich wollte nicht schreien. Es war ein sehr langer Tag für uns alle.
+Danke für alles, was du für diese Familie getan hast. Ohne dich wären wir verloren.
+Lass uns einen Tee trinken und auf sie warten. Alles wird gut, das verspreche ich dir.
+Wer war der Mann heute Morgen an der Tür? Er wollte mit deiner Mutter sprechen.
+Ich habe ihn noch nie gesehen. Er sagte, dass er morgen Nachmittag wiederkommt.`,
+
+	"ita": `Che cosa ci fai qui? Pensavo che stasera saresti rimasto a casa.
+Non riuscivo a dormire, così sono venuto a vedere se avevi bisogno di aiuto con la barca.
+Sai che non mi piace quando cammini da solo nel bosco di notte.
+Non preoccuparti per me. Dov'è tuo padre? Ha detto che sarebbe tornato prima di cena.
+È andato in città con tuo fratello. Sarebbero dovuti arrivare un'ora fa.
+Qualcosa non va, me lo sento. Dobbiamo trovarli prima che arrivi la tempesta.
+Ascoltami, non andiamo da nessuna parte finché non smette di piovere. È chiaro?
+Mi dispiace, non volevo gridare. È stata una giornata molto lunga per tutti noi.
+Grazie per tutto quello che hai fatto per questa famiglia. Senza di te saremmo perduti.
+Prendiamo un tè e aspettiamoli. Andrà tutto bene, te lo prometto.
+Chi era quell'uomo alla porta stamattina? Voleva parlare con tua madre.
+Non l'avevo mai visto prima. Ha detto che sarebbe tornato domani pomeriggio.`,
+
+	"por": `O que você está fazendo aqui? Pensei que ia ficar em casa esta noite.
+Não conseguia dormir, então vim ver se você precisava de ajuda com o barco.
+Você sabe que eu não gosto quando anda sozinho pela floresta à noite.
+Não se preocupe comigo. Onde está o seu pai? Ele disse que voltaria antes do jantar.
+Ele foi para a cidade com o seu irmão. Eles deveriam ter chegado há uma hora.
+Alguma coisa está errada, eu sinto isso. Temos que encontrá-los antes que a tempestade chegue.
+Escute, não vamos a lugar nenhum até a chuva parar. Está claro?
+Desculpe, não queria gritar. Foi um dia muito longo para todos nós.
+Obrigado por tudo o que você fez por esta família. Sem você estaríamos perdidos.
+Vamos tomar um chá e esperar por eles. Vai ficar tudo bem, eu prometo.
+Quem era aquele homem na porta hoje de manhã? Ele queria falar com a sua mãe.
+Eu nunca o tinha visto. Ele disse que voltaria amanhã à tarde.`,
+
+	"dut": `Wat doe jij hier? Ik dacht dat je vanavond thuis zou blijven.
+Ik kon niet slapen, dus ik kwam kijken of je hulp nodig had met de boot.
+Je weet dat ik het niet fijn vind als je 's nachts alleen door het bos loopt.
+Maak je geen zorgen om mij. Waar is je vader? Hij zei dat hij voor het eten terug zou zijn.
+Hij is met je broer naar de stad gegaan. Ze hadden een uur geleden hier moeten zijn.
+Er is iets mis, ik voel het. We moeten ze vinden voordat de storm komt.
+Luister naar me, we gaan nergens heen totdat het ophoudt met regenen. Is dat duidelijk?
+Het spijt me, ik wilde niet schreeuwen. Het was een heel lange dag voor ons allemaal.
+Bedankt voor alles wat je voor deze familie hebt gedaan. Zonder jou waren we verloren.
+Laten we thee drinken en op ze wachten. Alles komt goed, dat beloof ik.
+Wie was die man vanochtend aan de deur? Hij wilde met je moeder praten.
+Ik had hem nog nooit gezien. Hij zei dat hij morgenmiddag terug zou komen.`,
+
+	"pol": `Co ty tutaj robisz? Myślałem, że zostaniesz dziś wieczorem w domu.
+Nie mogłem spać, więc przyszedłem zobaczyć, czy potrzebujesz pomocy przy łodzi.
+Wiesz, że nie lubię, kiedy chodzisz sam po lesie w nocy.
+Nie martw się o mnie. Gdzie jest twój ojciec? Powiedział, że wróci przed kolacją.
+Pojechał do miasta z twoim bratem. Powinni tu być godzinę temu.
+Coś jest nie tak, czuję to. Musimy ich znaleźć, zanim przyjdzie burza.
+Posłuchaj mnie, nigdzie nie idziemy, dopóki nie przestanie padać. Czy to jasne?
+Przepraszam, nie chciałem krzyczeć. To był bardzo długi dzień dla nas wszystkich.
+Dziękuję za wszystko, co zrobiłeś dla tej rodziny. Bez ciebie bylibyśmy zgubieni.
+Napijmy się herbaty i poczekajmy na nich. Wszystko będzie dobrze, obiecuję.
+Kim był ten mężczyzna przy drzwiach dziś rano? Chciał porozmawiać z twoją matką.
+Nigdy wcześniej go nie widziałem. Powiedział, że wróci jutro po południu.`,
+
+	"rus": `Что ты здесь делаешь? Я думал, что ты сегодня вечером останешься дома.
+Я не мог уснуть, поэтому пришёл посмотреть, не нужна ли тебе помощь с лодкой.
+Ты знаешь, что мне не нравится, когда ты ходишь один по лесу ночью.
+Не беспокойся обо мне. Где твой отец? Он сказал, что вернётся до ужина.
+Он поехал в город с твоим братом. Они должны были приехать час назад.
+Что-то не так, я это чувствую. Мы должны найти их до того, как начнётся буря.
+Послушай меня, мы никуда не пойдём, пока не кончится дождь. Это понятно?
+Прости, я не хотел кричать. Это был очень длинный день для всех нас.
+Спасибо за всё, что ты сделал для этой семьи. Без тебя мы бы пропали.
+Давай выпьем чаю и подождём их. Всё будет хорошо, я обещаю.
+Кто был тот человек у двери сегодня утром? Он хотел поговорить с твоей матерью.
+Я никогда раньше его не видел. Он сказал, что вернётся завтра днём.`,
+}
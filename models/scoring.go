@@ -0,0 +1,304 @@
+package models
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+VideoWeights sets how much each property of the video tracks counts towards
+their score. Each property is rated from 0 to 1 before being weighted.
+*/
+type VideoWeights struct {
+	Resolution float64
+	Codec      float64
+	BitDepth   float64
+	HDR        float64
+	Bitrate    float64
+	Position   float64
+}
+
+/*
+DefaultVideoWeights favour the resolution over the codec, and both over the bit
+depth, HDR and bitrate, leaving the input position as a tie breaker.
+*/
+var DefaultVideoWeights = VideoWeights{
+	Resolution: 4,
+	Codec:      2,
+	BitDepth:   1,
+	HDR:        1,
+	Bitrate:    1,
+	Position:   0.01,
+}
+
+/*
+ParseVideoWeights parses a list of weights, like "resolution=2,codec=3",
+keeping the default weight of the properties not given
+*/
+func ParseVideoWeights(value string) (weights VideoWeights, err error) {
+	weights = DefaultVideoWeights
+
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 {
+			return weights, fmt.Errorf("invalid video weight %q, expected property=weight", item)
+		}
+
+		weight, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || weight < 0 {
+			return weights, fmt.Errorf("invalid video weight %q, weights must be positive numbers", item)
+		}
+
+		switch strings.ToLower(parts[0]) {
+		case "resolution":
+			weights.Resolution = weight
+		case "codec":
+			weights.Codec = weight
+		case "bitdepth":
+			weights.BitDepth = weight
+		case "hdr":
+			weights.HDR = weight
+		case "bitrate":
+			weights.Bitrate = weight
+		case "position":
+			weights.Position = weight
+		default:
+			return weights, fmt.Errorf("unknown video property %q, expected resolution, codec, bitdepth, hdr, bitrate or position", parts[0])
+		}
+	}
+
+	return
+}
+
+/*
+VideoScore is the score of a video track, along with the (already weighted)
+contribution of each one of its properties
+*/
+type VideoScore struct {
+	Video      TrackController
+	Resolution float64
+	Codec      float64
+	BitDepth   float64
+	HDR        float64
+	Bitrate    float64
+	Position   float64
+	Score      float64
+}
+
+// Codecs rating, by their Matroska codec IDs
+var videoCodecRatings = map[string]float64{
+	"V_AV1":            1,
+	"V_MPEGH/ISO/HEVC": 0.9,
+	"V_VP9":            0.8,
+	"V_MPEG4/ISO/AVC":  0.6,
+	"V_VP8":            0.4,
+	"V_MPEG4/ISO/ASP":  0.3,
+	"V_MPEG2":          0.2,
+	"V_MPEG1":          0.2,
+}
+
+// HDR formats rating, as returned by GetHDR
+var hdrRatings = map[string]float64{
+	"Dolby Vision": 1,
+	"HDR10":        0.9,
+	"HLG":          0.7,
+}
+
+// Matroska transfer characteristics (as defined by ITU-T H.273)
+const (
+	transferPQ  = 16
+	transferHLG = 18
+)
+
+/*
+ScoreVideos rates all the video tracks, returning them sorted from the best to
+the worst one. Resolution and bitrate are rated relative to the best of the
+tracks, and so is the position (later inputs are preferred).
+*/
+func (t *TracksController) ScoreVideos() []VideoScore {
+	weights := t.Preferences.videoWeights()
+
+	var pixels, bitrate uint64
+	var position int
+	for _, video := range t.Videos {
+		if value := video.Track.GetPixels(); value > pixels {
+			pixels = value
+		}
+		if value := video.Track.GetBitrate(); value > bitrate {
+			bitrate = value
+		}
+		if value := video.position(); value > position {
+			position = value
+		}
+	}
+
+	scores := make([]VideoScore, len(t.Videos))
+	for i, video := range t.Videos {
+		score := VideoScore{
+			Video:      video,
+			Resolution: weights.Resolution * ratio(video.Track.GetPixels(), pixels),
+			Codec:      weights.Codec * video.Track.codecRating(),
+			BitDepth:   weights.BitDepth * bitDepthRating(video.Track.GetBitDepth()),
+			HDR:        weights.HDR * hdrRatings[video.Track.GetHDR()],
+			Bitrate:    weights.Bitrate * ratio(video.Track.GetBitrate(), bitrate),
+			Position:   weights.Position * ratio(uint64(video.position()), uint64(position)),
+		}
+		score.Score = score.Resolution + score.Codec + score.BitDepth + score.HDR + score.Bitrate + score.Position
+		scores[i] = score
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		// Same score, use position priority setting
+		if scores[i].Score == scores[j].Score {
+			return scores[i].Video.position() > scores[j].Video.position()
+		}
+
+		return scores[i].Score > scores[j].Score
+	})
+
+	return scores
+}
+
+func ratio(value, max uint64) float64 {
+	if max == 0 {
+		return 0
+	}
+
+	return float64(value) / float64(max)
+}
+
+// position returns the input position, or 0 when the input is unknown
+func (track TrackController) position() int {
+	if track.Input == nil {
+		return 0
+	}
+
+	return track.Input.Position
+}
+
+// bitDepthRating rates 8 bits videos as 0 and 12 bits ones as 1
+func bitDepthRating(depth int) float64 {
+	switch {
+	case depth <= 8:
+		return 0
+	case depth >= 12:
+		return 1
+	}
+
+	return float64(depth-8) / 4
+}
+
+func (track *Track) codecRating() float64 {
+	if rating, ok := videoCodecRatings[track.Properties.CodecID]; ok {
+		return rating
+	}
+
+	// Tracks without codec ID (like some fixtures) are rated by codec name
+	for id, name := range codecNames {
+		if name == track.Codec {
+			return videoCodecRatings[id]
+		}
+	}
+
+	return 0
+}
+
+/*
+GetResolution returns the width and height (in pixels) of a video track, taken
+from its pixel dimensions or, when unknown, its display ones
+*/
+func (track *Track) GetResolution() (width, height int) {
+	dimensions := track.Properties.PixelDimensions
+	if dimensions == "" && track.Properties.Dimensions != nil {
+		dimensions = *track.Properties.Dimensions
+	}
+
+	parts := strings.Split(dimensions, "x")
+	if len(parts) != 2 {
+		return 0, 0
+	}
+
+	width, errw := strconv.Atoi(parts[0])
+	height, errh := strconv.Atoi(parts[1])
+	if errw != nil || errh != nil {
+		return 0, 0
+	}
+
+	return
+}
+
+/*
+GetPixels returns the number of pixels of each frame of a video track, or 0 if
+unknown
+*/
+func (track *Track) GetPixels() uint64 {
+	width, height := track.GetResolution()
+
+	return uint64(width) * uint64(height)
+}
+
+/*
+GetBitDepth returns the bits per color channel of a video track, taken from its
+color properties or its codec private data, or 0 if unknown
+*/
+func (track *Track) GetBitDepth() int {
+	if track.Properties.ColorBitsPerChannel > 0 {
+		return track.Properties.ColorBitsPerChannel
+	}
+
+	data, err := hex.DecodeString(track.Properties.CodecPrivateData)
+	if err != nil {
+		return 0
+	}
+
+	switch track.Properties.CodecID {
+	case "V_MPEGH/ISO/HEVC":
+		// HEVCDecoderConfigurationRecord bitDepthLumaMinus8
+		if len(data) > 17 {
+			return int(data[17]&0x07) + 8
+		}
+	case "V_MPEG4/ISO/AVC":
+		// AVCDecoderConfigurationRecord profile (High 10 and High 4:2:2)
+		if len(data) > 1 && (data[1] == 110 || data[1] == 122) {
+			return 10
+		}
+		if len(data) > 1 {
+			return 8
+		}
+	case "V_AV1":
+		// AV1CodecConfigurationRecord high_bitdepth and twelve_bit flags
+		if len(data) > 2 {
+			switch {
+			case data[2]&0x60 == 0x60:
+				return 12
+			case data[2]&0x40 != 0:
+				return 10
+			}
+			return 8
+		}
+	case "V_MPEG2", "V_MPEG1", "V_MPEG4/ISO/ASP":
+		return 8
+	}
+
+	return 0
+}
+
+/*
+GetHDR returns the HDR format of a video track (Dolby Vision, HDR10 or HLG), or
+an empty string for SDR and unknown tracks
+*/
+func (track *Track) GetHDR() string {
+	switch {
+	case track.Properties.DolbyVision:
+		return "Dolby Vision"
+	case track.Properties.ColorTransferCharacteristics == transferPQ:
+		return "HDR10"
+	case track.Properties.ColorTransferCharacteristics == transferHLG:
+		return "HLG"
+	}
+
+	return ""
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func scoredVideo(position int, codecID, dimensions, bps string) TrackController {
+	return TrackController{
+		Input: &Info{Position: position},
+		Track: &Track{
+			ID:    uint(position),
+			Type:  "video",
+			Codec: codecName(codecID),
+			Properties: properties{
+				CodecID:         codecID,
+				PixelDimensions: dimensions,
+				TagBps:          bps,
+			},
+		},
+	}
+}
+
+func TestGetBestVideoComparesResolutionsNumerically(t *testing.T) {
+	tracks := TracksController{
+		Videos: Tracks{
+			scoredVideo(0, "V_MPEG4/ISO/AVC", "1920x1080", ""),
+			scoredVideo(1, "V_MPEG4/ISO/AVC", "1280x720", ""),
+		},
+	}
+
+	video, err := tracks.GetBestVideo()
+
+	tests.Ok(t, err)
+	tests.Equals(t, "1920x1080", video.Track.Properties.PixelDimensions)
+}
+
+func TestScoreVideosPrefersUHDReleasesOverHDRemuxes(t *testing.T) {
+	remux := scoredVideo(0, "V_MPEG4/ISO/AVC", "1920x1080", "30000000")
+	remux.Track.Properties.CodecPrivateData = "0164002a"
+	web := scoredVideo(1, "V_MPEGH/ISO/HEVC", "3840x2160", "15000000")
+	web.Track.Properties.ColorBitsPerChannel = 10
+	web.Track.Properties.ColorTransferCharacteristics = transferPQ
+
+	tracks := TracksController{Videos: Tracks{remux, web}}
+	scores := tracks.ScoreVideos()
+
+	tests.Equals(t, web.Track, scores[0].Video.Track)
+	tests.Equals(t, VideoScore{
+		Video:      web,
+		Resolution: 4,
+		Codec:      1.8,
+		BitDepth:   0.5,
+		HDR:        0.9,
+		Bitrate:    0.5,
+		Position:   0.01,
+		Score:      7.71,
+	}, scores[0])
+	tests.Equals(t, 8, remux.Track.GetBitDepth())
+
+	// Unless the resolution does not matter that much
+	tracks.Preferences.VideoWeights = &VideoWeights{Resolution: 1, Codec: 1, Bitrate: 4}
+	video, err := tracks.GetBestVideo()
+
+	tests.Ok(t, err)
+	tests.Equals(t, remux.Track, video.Track)
+}
+
+func TestGetBitDepthReadsCodecPrivateData(t *testing.T) {
+	hevc := &Track{Properties: properties{
+		CodecID:          "V_MPEGH/ISO/HEVC",
+		CodecPrivateData: "01022000000090000000000099f000fcfdfaf800000f",
+	}}
+	av1 := &Track{Properties: properties{CodecID: "V_AV1", CodecPrivateData: "81084c"}}
+	avc := &Track{Properties: properties{CodecID: "V_MPEG4/ISO/AVC", CodecPrivateData: "016e0028"}}
+	unknown := &Track{Properties: properties{CodecID: "V_VP9"}}
+
+	tests.Equals(t, 10, hevc.GetBitDepth())
+	tests.Equals(t, 10, av1.GetBitDepth())
+	tests.Equals(t, 10, avc.GetBitDepth())
+	tests.Equals(t, 0, unknown.GetBitDepth())
+}
+
+func TestParseVideoWeights(t *testing.T) {
+	weights, err := ParseVideoWeights("codec=3, HDR=0")
+
+	tests.Ok(t, err)
+	expected := DefaultVideoWeights
+	expected.Codec = 3
+	expected.HDR = 0
+	tests.Equals(t, expected, weights)
+
+	_, err = ParseVideoWeights("codec")
+	tests.Assert(t, err != nil, "weights without value must fail")
+	_, err = ParseVideoWeights("sharpness=1")
+	tests.Assert(t, err != nil, "unknown properties must fail")
+	_, err = ParseVideoWeights("codec=-1")
+	tests.Assert(t, err != nil, "negative weights must fail")
+}
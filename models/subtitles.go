@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+DefaultSubtitleFormats prefers text subtitles over image based ones
+*/
+var DefaultSubtitleFormats = []string{"ass", "srt", "webvtt", "textst", "pgs", "vobsub", "dvb"}
+
+// Subtitle formats by their Matroska codec IDs
+var subtitleFormats = map[string]string{
+	"S_TEXT/ASS":    "ass",
+	"S_TEXT/SSA":    "ass",
+	"S_TEXT/UTF8":   "srt",
+	"S_TEXT/WEBVTT": "webvtt",
+	"S_HDMV/TEXTST": "textst",
+	"S_HDMV/PGS":    "pgs",
+	"S_VOBSUB":      "vobsub",
+	"S_DVBSUB":      "dvb",
+}
+
+// Subtitles with less events than this ratio of the most complete subtitles of
+// their language are taken as forced
+const forcedEventsRatio = 0.2
+
+/*
+GetSubtitleFormat returns the format of a subtitle track, as used by the
+subtitle formats ranking (like srt or pgs), or an empty string if unknown
+*/
+func (track *Track) GetSubtitleFormat() string {
+	return subtitleFormats[track.Properties.CodecID]
+}
+
+/*
+ParseSubtitleFormats parses a comma separated subtitle formats ranking, like
+"srt,ass,pgs", failing with unknown formats
+*/
+func ParseSubtitleFormats(value string) (formats []string, err error) {
+	for _, format := range strings.Split(value, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !containsString(DefaultSubtitleFormats, format) {
+			return nil, fmt.Errorf(
+				"unknown subtitle format %q, expected %s",
+				format,
+				strings.Join(DefaultSubtitleFormats, ", "),
+			)
+		}
+		formats = append(formats, format)
+	}
+
+	return
+}
+
+// sortSubtitles sorts the subtitles by the formats ranking (formats out of it
+// go last), then by their number of events and size, keeping the input order
+// of the equivalent ones
+func (preferences Preferences) sortSubtitles(subtitles Tracks) {
+	sort.SliceStable(subtitles, func(i, j int) bool {
+		return preferences.subtitleAdvantage(subtitles[i], subtitles[j]) != ""
+	})
+}
+
+// subtitleAdvantage returns why the subtitle a is preferred over b, or an empty
+// string when it's not
+func (preferences Preferences) subtitleAdvantage(a, b TrackController) string {
+	rankA, rankB := preferences.subtitleRank(a), preferences.subtitleRank(b)
+	eventsA, eventsB := a.Track.GetFrames(), b.Track.GetFrames()
+
+	switch {
+	case rankA != rankB:
+		return advantage(rankA < rankB, "better format rank")
+	case eventsA != eventsB:
+		return advantage(eventsA > eventsB, "more events")
+	}
+
+	return advantage(a.Track.GetBytes() > b.Track.GetBytes(), "bigger size")
+}
+
+// subtitleRank returns the position of the subtitle format in the ranking,
+// with the formats out of it going last
+func (preferences Preferences) subtitleRank(track TrackController) int {
+	formats := preferences.subtitleFormats()
+	for i, format := range formats {
+		if format == track.Track.GetSubtitleFormat() {
+			return i
+		}
+	}
+
+	return len(formats)
+}
+
+/*
+DetectForcedSubtitles flags as forced the main subtitles (see Track.Kind) with
+way less events than the most complete subtitles of their language, as forced
+subtitles are not always tagged as such. The number of events is taken from
+the statistics tags, so subtitles without them are never flagged.
+*/
+func (controller TracksController) DetectForcedSubtitles() {
+	events := map[string]uint64{}
+	for _, subtitle := range controller.Subtitles {
+		language := subtitle.Track.GetLanguage()
+		if subtitle.Track.Kind() == KindMain && subtitle.Track.GetFrames() > events[language] {
+			events[language] = subtitle.Track.GetFrames()
+		}
+	}
+
+	for _, subtitle := range controller.Subtitles {
+		track := subtitle.Track
+		if track.Properties.Forced || track.Kind() != KindMain || track.GetFrames() == 0 {
+			continue
+		}
+
+		if float64(track.GetFrames()) < forcedEventsRatio*float64(events[track.GetLanguage()]) {
+			track.Properties.Forced = true
+			track.ForcedDetected = true
+		}
+	}
+}
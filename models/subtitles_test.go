@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func rankedSubtitle(id uint, codecID string, forced bool, frames, bytes string) TrackController {
+	return TrackController{
+		Input: &Info{Position: 0},
+		Track: &Track{
+			ID:   id,
+			Type: "subtitles",
+			Properties: properties{
+				CodecID:           codecID,
+				Language:          "eng",
+				Forced:            forced,
+				TagNumberOfFrames: frames,
+				TagNumberOfBytes:  bytes,
+			},
+		},
+	}
+}
+
+func TestGetBestSubtitlesRanksByFormatAndCompleteness(t *testing.T) {
+	pgs := rankedSubtitle(0, "S_HDMV/PGS", false, "1500", "30000000")
+	partial := rankedSubtitle(1, "S_TEXT/UTF8", false, "900", "40000")
+	srt := rankedSubtitle(2, "S_TEXT/UTF8", false, "1400", "60000")
+	forced := rankedSubtitle(3, "S_VOBSUB", true, "20", "90000")
+	tracks := TracksController{Subtitles: Tracks{pgs, forced, partial, srt}}
+
+	tests.Equals(t, Tracks{srt, forced}, tracks.GetBestSubtitles([]string{"eng"}))
+
+	tracks.Preferences.SubtitleFormats = []string{"pgs"}
+	tests.Equals(t, Tracks{pgs, forced}, tracks.GetBestSubtitles([]string{"eng"}))
+}
+
+func TestDetectForcedSubtitlesFlagsTracksWithFewEvents(t *testing.T) {
+	full := rankedSubtitle(0, "S_TEXT/UTF8", false, "1200", "")
+	untagged := rankedSubtitle(1, "S_TEXT/UTF8", false, "12", "")
+	unknown := rankedSubtitle(2, "S_TEXT/UTF8", false, "", "")
+	signs := rankedSubtitle(3, "S_TEXT/ASS", false, "40", "")
+	signs.Track.Properties.TrackName = "Signs & Songs"
+	spanish := rankedSubtitle(4, "S_TEXT/UTF8", false, "100", "")
+	spanish.Track.Properties.Language = "spa"
+	tracks := TracksController{Subtitles: Tracks{untagged, full, unknown, signs, spanish}}
+
+	tracks.DetectForcedSubtitles()
+
+	tests.Equals(t, true, untagged.Track.Properties.Forced)
+	tests.Equals(t, true, untagged.Track.ForcedDetected)
+	tests.Equals(t, false, full.Track.Properties.Forced)
+	tests.Equals(t, false, unknown.Track.Properties.Forced)
+	tests.Equals(t, KindSigns, signs.Track.Kind())
+	tests.Equals(t, false, spanish.Track.Properties.Forced)
+	// So the full track is the main one
+	tests.Equals(t, Tracks{full, untagged}, tracks.GetBestSubtitles([]string{"eng"}))
+}
+
+func TestParseSubtitleFormats(t *testing.T) {
+	formats, err := ParseSubtitleFormats("SRT, pgs")
+	tests.Ok(t, err)
+	tests.Equals(t, []string{"srt", "pgs"}, formats)
+
+	_, err = ParseSubtitleFormats("srt,sub")
+	tests.Equals(t, `unknown subtitle format "sub", expected ass, srt, webvtt, textst, pgs, vobsub, dvb`, err.Error())
+}
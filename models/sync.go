@@ -0,0 +1,331 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// Sample rate used to decode the audios being synchronized
+	syncSampleRate = 8000
+	// Length of each one of the envelope windows (10ms at 8kHz)
+	syncEnvelopeSamples = syncSampleRate / 100
+	syncEnvelopeWindow  = 10 * time.Millisecond
+	// Minimum correlation required to use the detected offset
+	minSyncConfidence = 0.5
+)
+
+const (
+	defaultSyncWindow    = 2 * time.Minute
+	defaultSyncMaxOffset = 30 * time.Second
+)
+
+/*
+Sync is the offset (and frame rate correction) applied to all the tracks of an
+input, so they're in sync with the video
+*/
+type Sync struct {
+	Offset     time.Duration
+	Confidence float64
+	// Set when the input runs at a different frame rate than the video
+	FrameRate      *FrameRate
+	VideoFrameRate *FrameRate
+}
+
+/*
+AudioDecoder decodes a fragment of an audio track as mono PCM samples
+*/
+type AudioDecoder interface {
+	Decode(track TrackController, start, length time.Duration, rate int) ([]float64, error)
+}
+
+/*
+FFmpegDecoder decodes audio tracks using ffmpeg
+*/
+type FFmpegDecoder struct{}
+
+/*
+Decode the given fragment of the audio track using ffmpeg
+*/
+func (FFmpegDecoder) Decode(track TrackController, start, length time.Duration, rate int) ([]float64, error) {
+	output, err := exec.Command(
+		"ffmpeg",
+		"-nostdin",
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", start.Seconds()),
+		"-t", fmt.Sprintf("%.3f", length.Seconds()),
+		"-i", track.Input.FileName,
+		"-map", fmt.Sprintf("0:a:%d", track.audioIndex()),
+		"-ac", "1",
+		"-ar", fmt.Sprint(rate),
+		"-f", "s16le",
+		"-",
+	).Output()
+
+	if exit, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("ffmpeg failed: %s", strings.TrimSpace(string(exit.Stderr)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pcm := make([]int16, len(output)/2)
+	if err = binary.Read(bytes.NewReader(output[:len(pcm)*2]), binary.LittleEndian, pcm); err != nil {
+		return nil, err
+	}
+
+	samples := make([]float64, len(pcm))
+	for i, sample := range pcm {
+		samples[i] = float64(sample) / math.MaxInt16
+	}
+
+	return samples, nil
+}
+
+// audioIndex returns the position of the track among the audio tracks of its
+// input, as used by ffmpeg's stream specifiers (0:a:N)
+func (track TrackController) audioIndex() (index int) {
+	for _, other := range track.Input.Tracks {
+		if other.Track == track.Track {
+			return
+		}
+		if other.Track.Type == "audio" {
+			index++
+		}
+	}
+
+	return
+}
+
+/*
+SyncError is returned when the offset of an input could not be detected
+*/
+type SyncError struct {
+	Input *Info
+	Err   error
+}
+
+func (err *SyncError) Error() string {
+	return fmt.Sprintf("%s: sync not detected: %v", err.Input.FileName, err.Err)
+}
+
+/*
+SyncErrors lists all the inputs whose offset could not be detected
+*/
+type SyncErrors []error
+
+func (errs SyncErrors) Error() string {
+	var lines []string
+	for _, err := range errs {
+		lines = append(lines, err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+/*
+SyncDetector finds the offset between the audios of different inputs by cross
+correlating the envelopes of a fragment of them.
+*/
+type SyncDetector struct {
+	Decoder AudioDecoder
+	// Length of the video audio fragment being compared (2 minutes if zero)
+	Window time.Duration
+	// Maximum offset looked for, in both directions (30 seconds if zero)
+	MaxOffset time.Duration
+}
+
+/*
+DetectSync compares the audio of the inputs of the given tracks with the audio
+of the video input, setting the detected offset to each input Sync. Inputs
+without audio are not synchronized, and the ones whose offset could not be
+detected are returned as SyncErrors.
+
+Inputs with a different frame rate (see DetectFrameRates) are stretched before
+comparing them, so it must be called after it.
+*/
+func (detector SyncDetector) DetectSync(video *TrackController, selections ...Tracks) error {
+	if detector.Window == 0 {
+		detector.Window = defaultSyncWindow
+	}
+	if detector.MaxOffset == 0 {
+		detector.MaxOffset = defaultSyncMaxOffset
+	}
+
+	reference := firstAudio(video.Input)
+	if reference == nil {
+		return SyncErrors{&SyncError{Input: video.Input, Err: fmt.Errorf("the video input has no audio")}}
+	}
+
+	var referenceEnvelope []float64
+	var errs SyncErrors
+	checked := map[*Info]bool{video.Input: true}
+
+	for _, tracks := range selections {
+		for _, track := range tracks {
+			if checked[track.Input] {
+				continue
+			}
+			checked[track.Input] = true
+
+			audio := &track
+			if track.Track.Type != "audio" {
+				if audio = firstAudio(track.Input); audio == nil {
+					continue
+				}
+			}
+
+			if referenceEnvelope == nil {
+				samples, err := detector.Decoder.Decode(*reference, detector.MaxOffset, detector.Window, syncSampleRate)
+				if err != nil {
+					return SyncErrors{&SyncError{Input: video.Input, Err: err}}
+				}
+				referenceEnvelope = envelope(samples)
+			}
+
+			// Inputs with another frame rate are stretched to the video one
+			factor := track.Input.Sync.factor()
+			length := time.Duration(float64(detector.Window+2*detector.MaxOffset) / factor)
+
+			samples, err := detector.Decoder.Decode(*audio, 0, length, syncSampleRate)
+			if err != nil {
+				errs = append(errs, &SyncError{Input: track.Input, Err: err})
+				continue
+			}
+
+			lag, confidence := correlate(referenceEnvelope, stretchEnvelope(envelope(samples), factor))
+			if confidence < minSyncConfidence {
+				errs = append(errs, &SyncError{
+					Input: track.Input,
+					Err:   fmt.Errorf("audios do not match (%.0f%% confidence)", confidence*100),
+				})
+				continue
+			}
+
+			if track.Input.Sync == nil {
+				track.Input.Sync = &Sync{}
+			}
+			// The reference fragment starts MaxOffset after the other one
+			track.Input.Sync.Offset = (detector.MaxOffset - lag).Round(time.Millisecond)
+			track.Input.Sync.Confidence = confidence
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func firstAudio(input *Info) *TrackController {
+	for i, track := range input.Tracks {
+		if track.Track.Type == "audio" {
+			return &input.Tracks[i]
+		}
+	}
+
+	return nil
+}
+
+// envelope returns the RMS of each 10ms window of the samples
+func envelope(samples []float64) []float64 {
+	windows := make([]float64, len(samples)/syncEnvelopeSamples)
+	for i := range windows {
+		var sum float64
+		for _, sample := range samples[i*syncEnvelopeSamples : (i+1)*syncEnvelopeSamples] {
+			sum += sample * sample
+		}
+		windows[i] = math.Sqrt(sum / syncEnvelopeSamples)
+	}
+
+	return windows
+}
+
+// stretchEnvelope resamples the envelope so it lasts factor times longer
+func stretchEnvelope(windows []float64, factor float64) []float64 {
+	if factor == 1 {
+		return windows
+	}
+
+	stretched := make([]float64, int(float64(len(windows))*factor))
+	for i := range stretched {
+		position := float64(i) / factor
+		j := int(position)
+		if j+1 >= len(windows) {
+			stretched[i] = windows[len(windows)-1]
+			continue
+		}
+		weight := position - float64(j)
+		stretched[i] = windows[j]*(1-weight) + windows[j+1]*weight
+	}
+
+	return stretched
+}
+
+// correlate finds the position of the reference envelope in the other one,
+// returning it as a duration along with its Pearson correlation coefficient.
+func correlate(reference, other []float64) (lag time.Duration, confidence float64) {
+	n := len(reference)
+	if n == 0 || len(other) < n {
+		return 0, 0
+	}
+
+	referenceMean, referenceDeviation := meanDeviation(reference)
+	if referenceDeviation == 0 {
+		return 0, 0
+	}
+
+	scores := make([]float64, len(other)-n+1)
+	best := -1
+	for k := range scores {
+		window := other[k : k+n]
+		mean, deviation := meanDeviation(window)
+		if deviation == 0 {
+			continue
+		}
+
+		var sum float64
+		for i, value := range window {
+			sum += (reference[i] - referenceMean) * (value - mean)
+		}
+		scores[k] = sum / (float64(n) * referenceDeviation * deviation)
+
+		if best < 0 || scores[k] > scores[best] {
+			best = k
+		}
+	}
+
+	if best < 0 {
+		return 0, 0
+	}
+
+	// Parabolic interpolation between the neighbour windows
+	position := float64(best)
+	if best > 0 && best < len(scores)-1 {
+		a, b, c := scores[best-1], scores[best], scores[best+1]
+		if denominator := a - 2*b + c; denominator != 0 {
+			position += 0.5 * (a - c) / denominator
+		}
+	}
+
+	return time.Duration(position * float64(syncEnvelopeWindow)), scores[best]
+}
+
+func meanDeviation(values []float64) (mean, deviation float64) {
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	for _, value := range values {
+		deviation += (value - mean) * (value - mean)
+	}
+
+	return mean, math.Sqrt(deviation / float64(len(values)))
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/elboletaire/remuxing/tests"
+)
+
+// fakeDecoder plays a random volume envelope, delayed for each input
+type fakeDecoder struct {
+	envelopes map[*Info][]float64
+	delays    map[*Info]time.Duration
+}
+
+func randomEnvelope(seed int64, length int) []float64 {
+	random := rand.New(rand.NewSource(seed))
+	envelope := make([]float64, length)
+	for i := range envelope {
+		envelope[i] = random.Float64()
+	}
+
+	return envelope
+}
+
+func (decoder fakeDecoder) Decode(track TrackController, start, length time.Duration, rate int) ([]float64, error) {
+	envelope := decoder.envelopes[track.Input]
+	samples := make([]float64, int(length.Seconds()*float64(rate)))
+	for i := range samples {
+		at := start + time.Duration(i)*time.Second/time.Duration(rate) - decoder.delays[track.Input]
+		// 25ms long sounds
+		if window := int(at / (25 * time.Millisecond)); at >= 0 && window < len(envelope) {
+			samples[i] = envelope[window]
+			if i%2 == 0 {
+				samples[i] = -samples[i]
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+func syncInput(name string) *Info {
+	input := &Info{FileName: name}
+	input.Tracks = Tracks{{Input: input, Track: &Track{Type: "audio"}}}
+
+	return input
+}
+
+func TestDetectSyncFindsTheOffsetOfEachInput(t *testing.T) {
+	video, dub, early, other := syncInput("video.mkv"), syncInput("dub.mkv"), syncInput("early.mka"), syncInput("other.mka")
+	program := randomEnvelope(1, 4000)
+
+	detector := SyncDetector{
+		Decoder: fakeDecoder{
+			envelopes: map[*Info][]float64{video: program, dub: program, early: program, other: randomEnvelope(2, 4000)},
+			delays:    map[*Info]time.Duration{video: 0, dub: 2500 * time.Millisecond, early: -1200 * time.Millisecond},
+		},
+		Window:    20 * time.Second,
+		MaxOffset: 5 * time.Second,
+	}
+
+	err := detector.DetectSync(&video.Tracks[0], Tracks{dub.Tracks[0], early.Tracks[0], other.Tracks[0]})
+
+	tests.Assert(t, err != nil, "expected other.mka not to be synced")
+	tests.Equals(t, 1, len(err.(SyncErrors)))
+	tests.Equals(t, other, err.(SyncErrors)[0].(*SyncError).Input)
+	tests.Assert(t, other.Sync == nil, "other.mka should not be synced")
+
+	tests.Assert(t, dub.Sync != nil && early.Sync != nil, "expected inputs to be synced")
+	tests.Assert(t, !exceeds(dub.Sync.Offset+2500*time.Millisecond, 10*time.Millisecond), "unexpected dub offset %s", dub.Sync.Offset)
+	tests.Assert(t, !exceeds(early.Sync.Offset-1200*time.Millisecond, 10*time.Millisecond), "unexpected early offset %s", early.Sync.Offset)
+	tests.Assert(t, dub.Sync.Confidence > 0.9, "unexpected confidence %f", dub.Sync.Confidence)
+}
+
+func TestDetectSyncRequiresVideoAudio(t *testing.T) {
+	video := &Info{FileName: "video.mkv"}
+	video.Tracks = Tracks{{Input: video, Track: &Track{Type: "video"}}}
+
+	err := SyncDetector{}.DetectSync(&video.Tracks[0], Tracks{syncInput("dub.mka").Tracks[0]})
+
+	tests.Equals(t, "video.mkv: sync not detected: the video input has no audio", err.Error())
+}
+
+func TestCheckDurationsTakesSyncIntoAccount(t *testing.T) {
+	video := durationInput("video.mkv", 100, "video")
+	dub := durationInput("dub.mka", 103, "audio")
+	videoTrack := &TrackController{Input: video, Track: video.Tracks[0].Track}
+	audios := Tracks{{Input: dub, Track: dub.Tracks[0].Track}}
+
+	tests.Assert(t, CheckDurations(videoTrack, time.Second, audios) != nil, "expected a mismatch")
+
+	dub.Sync = &Sync{Offset: -3 * time.Second}
+	tests.Ok(t, CheckDurations(videoTrack, time.Second, audios))
+}
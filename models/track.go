@@ -3,15 +3,43 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type properties struct {
-	CodecID    string  `json:"codec_id"`
-	Dimensions *string `json:"display_dimensions"`
-	Language   string  `json:"language"`
-	Forced     bool    `json:"forced_track"`
-	Default    bool    `json:"default_track"`
+	CodecID                      string  `json:"codec_id"`
+	CodecPrivateData             string  `json:"codec_private_data,omitempty"`
+	CodecPrivateLength           int     `json:"codec_private_length,omitempty"`
+	Dimensions                   *string `json:"display_dimensions"`
+	PixelDimensions              string  `json:"pixel_dimensions,omitempty"`
+	ColorBitsPerChannel          int     `json:"color_bits_per_channel,omitempty"`
+	ColorTransferCharacteristics int     `json:"color_transfer_characteristics,omitempty"`
+	// Not reported by mkvmerge, only by the ffprobe and native probers
+	DolbyVision            bool   `json:"dolby_vision,omitempty"`
+	DefaultDuration        uint64 `json:"default_duration,omitempty"`
+	AudioChannels          int    `json:"audio_channels,omitempty"`
+	AudioSamplingFrequency int    `json:"audio_sampling_frequency,omitempty"`
+	AudioBitsPerSample     int    `json:"audio_bits_per_sample,omitempty"`
+	AudioEmphasis          int    `json:"audio_emphasis,omitempty"`
+	Language               string `json:"language"`
+	LanguageIETF           string `json:"language_ietf,omitempty"`
+	TrackName              string `json:"track_name,omitempty"`
+	Encoding               string `json:"encoding,omitempty"`
+	Forced                 bool   `json:"forced_track"`
+	Default                bool   `json:"default_track"`
+	Original               bool   `json:"flag_original,omitempty"`
+	Commentary             bool   `json:"flag_commentary,omitempty"`
+	HearingImpaired        bool   `json:"flag_hearing_impaired,omitempty"`
+	VisualImpaired         bool   `json:"flag_visual_impaired,omitempty"`
+	UID                    uint64 `json:"uid,omitempty"`
+	Number                 uint   `json:"number,omitempty"`
+	MinimumTimestamp       uint64 `json:"minimum_timestamp,omitempty"`
+	TagBps                 string `json:"tag_bps,omitempty"`
+	TagDuration            string `json:"tag_duration,omitempty"`
+	TagNumberOfFrames      string `json:"tag_number_of_frames,omitempty"`
+	TagNumberOfBytes       string `json:"tag_number_of_bytes,omitempty"`
 }
 
 /*
@@ -22,6 +50,17 @@ type Track struct {
 	Type       string     `json:"type"`
 	Codec      string     `json:"codec"`
 	Properties properties `json:"properties"`
+	// Set when the language was guessed by InferLanguages
+	Inference *Inference `json:"inference,omitempty"`
+	// Set when the track was flagged as forced by DetectForcedSubtitles
+	ForcedDetected bool `json:"forced_detected,omitempty"`
+	// Name of the output track, set by the interactive mode. Names are
+	// removed when empty, but for videos.
+	OutputName string `json:"-"`
+	// Override the default and forced track flags of the output, set by the
+	// interactive mode
+	OutputDefault *bool `json:"-"`
+	OutputForced  *bool `json:"-"`
 }
 
 /*
@@ -39,6 +78,13 @@ func (track *TrackController) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, &track.Track)
 }
 
+/*
+MarshalJSON only exports the Track, the same way it's unmarshaled
+*/
+func (track TrackController) MarshalJSON() ([]byte, error) {
+	return json.Marshal(track.Track)
+}
+
 /*
 SetInfo ...
 */
@@ -55,6 +101,66 @@ func (track *Track) GetHeight() string {
 	return strings.Split(*track.Properties.Dimensions, "x")[1]
 }
 
+/*
+GetBitrate returns the bitrate (in bits per second) from the statistics tags,
+or 0 if unknown
+*/
+func (track *Track) GetBitrate() uint64 {
+	return parseTagUint(track.Properties.TagBps)
+}
+
+/*
+GetFrames returns the number of frames (or subtitle events) from the
+statistics tags, or 0 if unknown
+*/
+func (track *Track) GetFrames() uint64 {
+	return parseTagUint(track.Properties.TagNumberOfFrames)
+}
+
+/*
+GetBytes returns the track size from the statistics tags, or 0 if unknown
+*/
+func (track *Track) GetBytes() uint64 {
+	return parseTagUint(track.Properties.TagNumberOfBytes)
+}
+
+/*
+GetDuration returns the track duration from the statistics tags, or 0 if
+unknown
+*/
+func (track *Track) GetDuration() time.Duration {
+	return parseTagDuration(track.Properties.TagDuration)
+}
+
+func parseTagUint(value string) uint64 {
+	number, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
+
+// parseTagDuration parses the HH:MM:SS.nnnnnnnnn format used by mkvmerge's
+// DURATION statistics tag.
+func parseTagDuration(value string) time.Duration {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 3 {
+		return 0
+	}
+
+	hours, errh := strconv.Atoi(parts[0])
+	minutes, errm := strconv.Atoi(parts[1])
+	seconds, errs := strconv.ParseFloat(parts[2], 64)
+	if errh != nil || errm != nil || errs != nil {
+		return 0
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second))
+}
+
 /*
 GetID returns the track id as string
 */
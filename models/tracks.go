@@ -1,7 +1,8 @@
 package models
 
 import (
-	"sort"
+	"runtime"
+	"sync"
 )
 
 // import "fmt"
@@ -10,9 +11,12 @@ import (
 TracksController stores all the tracks information
 */
 type TracksController struct {
+	Inputs    []*Info
 	Audios    Tracks
 	Videos    Tracks
 	Subtitles Tracks
+	// How the best tracks are chosen
+	Preferences Preferences
 }
 
 /*
@@ -21,19 +25,26 @@ Tracks is the definition for the slice of *Track
 type Tracks []TrackController
 
 /*
-BuildTracks creates a new TracksController instance
+BuildTracks creates a new TracksController instance, identifying the inputs
+with the given prober using up to the given number of concurrent workers (or
+one per CPU if zero).
+
+All the inputs are always identified, returning an InputErrors with every
+input that failed.
 */
-func BuildTracks(inputs []string) (tracks TracksController) {
-	for pos, input := range inputs {
-		info, err := GetFileInfo(input)
-		if err != nil {
-			panic(err)
-		}
+func BuildTracks(prober Prober, inputs []string, workers int) (tracks TracksController, err error) {
+	infos, err := probeInputs(prober, inputs, workers)
+	if err != nil {
+		return
+	}
 
+	for pos := range infos {
+		info := &infos[pos]
 		info.SetPosition(pos)
+		tracks.Inputs = append(tracks.Inputs, info)
 
 		for _, track := range info.Tracks {
-			track.SetInfo(&info)
+			track.SetInfo(info)
 			switch track.Track.Type {
 			case "audio":
 				tracks.Audios = append(tracks.Audios, track)
@@ -48,6 +59,49 @@ func BuildTracks(inputs []string) (tracks TracksController) {
 	return
 }
 
+func probeInputs(prober Prober, inputs []string, workers int) (infos []Info, err error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	infos = make([]Info, len(inputs))
+	failures := make([]error, len(inputs))
+	positions := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pos := range positions {
+				infos[pos], failures[pos] = prober.Probe(inputs[pos])
+			}
+		}()
+	}
+
+	for pos := range inputs {
+		positions <- pos
+	}
+	close(positions)
+	wg.Wait()
+
+	var errs InputErrors
+	for pos, failure := range failures {
+		if failure != nil {
+			errs = append(errs, inputError(inputs[pos], pos, failure))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return
+}
+
 /*
 Filter allows you to filter tracks by a given condition
 */
@@ -62,142 +116,122 @@ func (t Tracks) Filter(test func(TrackController) bool) (ret Tracks) {
 }
 
 /*
-HEVCFilter is used by filter function to filter HEVC sources
+GetBestVideo returns a pointer to the best available video source track, based
+on their scores (see ScoreVideos)
 */
-func HEVCFilter(track TrackController) bool {
-	return track.Track.Codec == "MPEG-H/HEVC/h.265"
-}
-
-func videoSorting(videos Tracks) Tracks {
-	sort.Slice(videos, func(i, j int) bool {
-		// In case they're of the same sice, use position priority setting
-		if videos[i].Track.GetHeight() == videos[j].Track.GetHeight() {
-			return videos[i].Input.Position > videos[j].Input.Position
-		}
-		// Take the biggest pixel density image
-		return videos[i].Track.GetHeight() < videos[j].Track.GetHeight()
-	})
-
-	return videos
-}
-
-/*
-GetBestVideo returns a pointer to the best available video source track
-*/
-func (t *TracksController) GetBestVideo() (video *TrackController) {
-	videos := t.Videos
-	// Try to find-out HEVC sources.
-	hevc := videos.Filter(HEVCFilter)
-
-	// If we found just one, return it
-	if len(hevc) == 1 {
-		return &hevc[0]
-	}
-
-	// Don't fuck your brain here, we just wanna sort hevc results in case there
-	// are results, otherwise what we want to sort is the original videos input
-	if len(hevc) > 0 {
-		videos = hevc
+func (t *TracksController) GetBestVideo() (video *TrackController, err error) {
+	if len(t.Videos) == 0 {
+		return nil, &NoVideoTrackError{}
 	}
 
-	// Sort videos by quality and priority
-	videos = videoSorting(videos)
+	scores := t.ScoreVideos()
 
-	// Best video should be first
-	video = &videos[0]
-
-	return
+	return &scores[0].Video, nil
 }
 
 /*
 GetBestAudios returns a list with the best available audio source tracks for
-the defined languages
+the defined languages (see GetAudioSet). Without languages all of them are
+returned, or just the best one of each language and kind when deduplicating.
 */
-func (t *TracksController) GetBestAudios(languages []string) (tracks Tracks) {
+func (t *TracksController) GetBestAudios(languages []string) (tracks Tracks, err error) {
+	if len(languages) == 0 && t.Preferences.Dedupe {
+		return t.dedupeAudios(), nil
+	}
 	if len(languages) == 0 {
-		return t.Audios
+		return t.Audios, nil
 	}
 
 	for _, language := range languages {
-		resulting := t.GetBestAudio(language)
-		tracks = append(tracks, resulting)
+		resulting, err := t.GetAudioSet(language)
+		if err != nil {
+			return nil, err
+		}
+		for _, audio := range resulting {
+			// Different codes for the same language (like es and spa)
+			if !tracks.contains(audio) {
+				tracks = append(tracks, audio)
+			}
+		}
 	}
 
-	return tracks
+	return tracks, nil
 }
 
-/*
-GetBestAudio among all tracks for the specified language.
-*/
-func (t *TracksController) GetBestAudio(language string) TrackController {
-	audios := t.Audios.Filter(func(track TrackController) bool {
-		return track.Track.Properties.Language == language
-	})
-
-	if len(audios) == 1 {
-		return audios[0]
-	}
-
-	// If there are more than one languages, filter the already filtered results
-	if len(audios) == 0 {
-		// Otherwise, filter again all inputs.
-		audios = t.Audios
+func (t Tracks) contains(track TrackController) bool {
+	for _, item := range t {
+		if item.Track == track.Track {
+			return true
+		}
 	}
 
-	filtered := extractWithCodecs(audios, []string{
-		"A_AAC",
-		"A_VORBIS",
-		"A_OPUS",
-		"A_AC3",
-	})
+	return false
+}
 
-	if len(filtered) > 0 {
-		return filtered[0]
+// filterLanguage returns the tracks matching the given language, keeping only
+// the ones matching its IETF subtags when there are any
+func (t Tracks) filterLanguage(language string) (tracks Tracks) {
+	best := 1
+	for _, track := range t {
+		match := track.Track.MatchLanguage(language)
+		if match > best {
+			best, tracks = match, nil
+		}
+		if match == best {
+			tracks = append(tracks, track)
+		}
 	}
 
-	// At the end, if there's no other audio we like, return the one with more priority.
-	sort.Slice(audios, func(i, j int) bool {
-		return audios[i].Input.Position > audios[j].Input.Position
-	})
-
-	return audios[0]
+	return
 }
 
-func extractWithCodecs(tracks Tracks, codecs []string) Tracks {
-	if track := extractWithCodec(tracks, codecs[0:1][0]); track != nil {
-		return track
-	}
+/*
+GetBestAudio among all the main tracks (see Track.Kind) for the specified
+language, based on the audio codecs ranking and their channels, bitrate and
+input position.
+*/
+func (t *TracksController) GetBestAudio(language string) (TrackController, error) {
+	audios := t.Preferences.audioRole("main", t.Audios.filterLanguage(language))
 
-	// No results could be found + there are no more codecs to search by
-	if len(codecs) <= 1 {
-		return nil
+	if len(audios) == 0 {
+		return TrackController{}, &LanguageNotFoundError{Type: "audio", Language: language}
 	}
 
-	codecs = codecs[1:]
-
-	return extractWithCodecs(tracks, codecs)
-}
-
-func extractWithCodec(tracks Tracks, codec string) Tracks {
-	return tracks.Filter(func(track TrackController) bool {
-		return track.Track.Properties.CodecID == codec
-	})
+	return audios[0], nil
 }
 
 /*
 GetBestSubtitles among all the tracks, based on given languages and custom definitions.
+Only main subtitles (see Track.Kind) are taken, unless other kinds are included
+in the preferences, and just the best forced and non forced subtitles of each
+kind, based on their format and number of events. Without languages all of them
+are returned, or just the best ones of each language when deduplicating.
 */
 func (t *TracksController) GetBestSubtitles(languages []string) (subtitles Tracks) {
+	if languages == nil && t.Preferences.Dedupe {
+		return t.dedupeSubtitles()
+	}
 	if languages == nil {
 		return t.Subtitles
 	}
 
 	for _, language := range languages {
-		best := t.GetBestSubtitlesForLanguage(language)
-		if len(best) > 1 {
-			best = reduceSubtitles(best)
+		candidates := t.GetBestSubtitlesForLanguage(language)
+		// Main subtitles, followed by the optional kinds asked for
+		kinds := append([]string{KindMain}, t.Preferences.IncludeKinds...)
+		for _, kind := range kinds {
+			best := candidates.Filter(kindFilter(kind))
+			if len(best) > 1 {
+				t.Preferences.sortSubtitles(best)
+				best = reduceSubtitles(best)
+			}
+			for _, subtitle := range best {
+				// Different codes for the same language (like es and spa)
+				if !subtitles.contains(subtitle) {
+					subtitles = append(subtitles, subtitle)
+				}
+			}
 		}
-		subtitles = append(subtitles, best...)
 	}
 
 	return
@@ -235,7 +269,5 @@ GetBestSubtitlesForLanguage returns all the subtitle tracks for the given langua
 Note that subtitles are always return as list, as it may contain forced or
 */
 func (t *TracksController) GetBestSubtitlesForLanguage(language string) (subtitles Tracks) {
-	return t.Subtitles.Filter(func(track TrackController) bool {
-		return track.Track.Properties.Language == language
-	})
+	return t.Subtitles.filterLanguage(language)
 }
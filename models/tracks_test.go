@@ -24,7 +24,10 @@ func TestGetBestVideoReturnsUniqueHEVCSource(t *testing.T) {
 		},
 	}
 
-	tests.Equals(t, "1", tracks.GetBestVideo().Track.GetID())
+	video, err := tracks.GetBestVideo()
+
+	tests.Ok(t, err)
+	tests.Equals(t, "1", video.Track.GetID())
 }
 
 func TestGetBestVideoDecidesBetweenHEVCSourcesBasedOnDimensions(t *testing.T) {
@@ -64,7 +67,10 @@ func TestGetBestVideoDecidesBetweenHEVCSourcesBasedOnDimensions(t *testing.T) {
 		},
 	}
 
-	tests.Equals(t, "0", tracks.GetBestVideo().Track.GetID())
+	video, err := tracks.GetBestVideo()
+
+	tests.Ok(t, err)
+	tests.Equals(t, "0", video.Track.GetID())
 }
 func TestGetBestVideoDecidesBetweenHEVCSourcesBasedOnPosition(t *testing.T) {
 	dimensions := "1920x1080"
@@ -110,7 +116,10 @@ func TestGetBestVideoDecidesBetweenHEVCSourcesBasedOnPosition(t *testing.T) {
 		},
 	}
 
-	tests.Equals(t, "0", tracks.GetBestVideo().Track.GetID())
+	video, err := tracks.GetBestVideo()
+
+	tests.Ok(t, err)
+	tests.Equals(t, "0", video.Track.GetID())
 }
 
 func TestGetBestVideoDecidesBetweenNonHEVCSourcesBasedOnPosition(t *testing.T) {
@@ -157,7 +166,10 @@ func TestGetBestVideoDecidesBetweenNonHEVCSourcesBasedOnPosition(t *testing.T) {
 		},
 	}
 
-	tests.Equals(t, "0", tracks.GetBestVideo().Track.GetID())
+	video, err := tracks.GetBestVideo()
+
+	tests.Ok(t, err)
+	tests.Equals(t, "0", video.Track.GetID())
 }
 
 func TestGetBestAudioReturnsTheOnlyOneWithSpecifiedLanguage(t *testing.T) {
@@ -182,7 +194,10 @@ func TestGetBestAudioReturnsTheOnlyOneWithSpecifiedLanguage(t *testing.T) {
 		},
 	}
 
-	tests.Equals(t, "1", tracks.GetBestAudio("spa").Track.GetID())
+	audio, err := tracks.GetBestAudio("spa")
+
+	tests.Ok(t, err)
+	tests.Equals(t, "1", audio.Track.GetID())
 }
 
 func TestGetBestAudioDecidesBetweenCodecsWhenMultipleSourcesOfSameLanguage(t *testing.T) {
@@ -209,7 +224,10 @@ func TestGetBestAudioDecidesBetweenCodecsWhenMultipleSourcesOfSameLanguage(t *te
 		},
 	}
 
-	tests.Equals(t, "1", tracks.GetBestAudios([]string{"eng"})[0].Track.GetID())
+	audios, err := tracks.GetBestAudios([]string{"eng"})
+
+	tests.Ok(t, err)
+	tests.Equals(t, "1", audios[0].Track.GetID())
 
 	tracks = TracksController{
 		Audios: Tracks{
@@ -234,7 +252,10 @@ func TestGetBestAudioDecidesBetweenCodecsWhenMultipleSourcesOfSameLanguage(t *te
 		},
 	}
 
-	tests.Equals(t, "0", tracks.GetBestAudios([]string{"eng"})[0].Track.GetID())
+	audios, err = tracks.GetBestAudios([]string{"eng"})
+
+	tests.Ok(t, err)
+	tests.Equals(t, "0", audios[0].Track.GetID())
 }
 
 func TestGetBestAudioDecidesBetweenCodecsBasedOnPositionIfNoKnownCodecsFound(t *testing.T) {
@@ -267,7 +288,37 @@ func TestGetBestAudioDecidesBetweenCodecsBasedOnPositionIfNoKnownCodecsFound(t *
 		},
 	}
 
-	tests.Equals(t, "0", tracks.GetBestAudios([]string{"eng"})[0].Track.GetID())
+	audios, err := tracks.GetBestAudios([]string{"eng"})
+
+	tests.Ok(t, err)
+	tests.Equals(t, "0", audios[0].Track.GetID())
+}
+
+func TestGetBestAudioFailsWhenLanguageIsNotAvailable(t *testing.T) {
+	tracks := TracksController{
+		Audios: Tracks{
+			TrackController{
+				Track: &Track{
+					ID: 0,
+					Properties: properties{
+						Language: "eng",
+					},
+				},
+			},
+		},
+	}
+
+	_, err := tracks.GetBestAudios([]string{"eng", "spa"})
+
+	tests.Equals(t, &LanguageNotFoundError{Type: "audio", Language: "spa"}, err)
+}
+
+func TestGetBestVideoFailsWithoutVideoTracks(t *testing.T) {
+	tracks := TracksController{}
+
+	_, err := tracks.GetBestVideo()
+
+	tests.Equals(t, &NoVideoTrackError{}, err)
 }
 
 func TestGetBestSubtitlesReturnsTheUniqueAvailableOption(t *testing.T) {
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/elboletaire/remuxing/models"
+)
+
+// languageOverrides is the repeatable -set-language flag
+type languageOverrides []models.LanguageOverride
+
+func (overrides *languageOverrides) String() string {
+	var values []string
+	for _, override := range *overrides {
+		values = append(values, override.String())
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func (overrides *languageOverrides) Set(value string) error {
+	override, err := parseLanguageOverride(value)
+	if err != nil {
+		return err
+	}
+
+	*overrides = append(*overrides, override)
+
+	return nil
+}
+
+// parseLanguageOverride parses "input.mkv:1=spa" and "2:1=spa" overrides. The
+// input is taken as a 1-based position when it's a number and there's no file
+// with that name.
+func parseLanguageOverride(value string) (override models.LanguageOverride, err error) {
+	invalid := fmt.Errorf("invalid language override %q, expected input:track=language", value)
+
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return override, invalid
+	}
+	override.Language = parts[1]
+
+	// File names may contain colons, track IDs can't
+	separator := strings.LastIndex(parts[0], ":")
+	if separator <= 0 {
+		return override, invalid
+	}
+
+	track, err := strconv.ParseUint(parts[0][separator+1:], 10, 32)
+	if err != nil {
+		return override, invalid
+	}
+	override.Track = uint(track)
+
+	input := parts[0][:separator]
+	if position, err := strconv.Atoi(input); err == nil {
+		if _, err := os.Stat(input); os.IsNotExist(err) {
+			if position < 1 {
+				return override, fmt.Errorf("invalid language override %q, inputs positions start at 1", value)
+			}
+			override.Position = position
+
+			return override, nil
+		}
+	}
+	override.Input = input
+
+	return override, nil
+}
+
+// checkLanguageOverrides ensures the overrides refer to one of the inputs,
+// either by file name or by the position of their argument
+func checkLanguageOverrides(overrides []models.LanguageOverride, jobs []remuxJob) error {
+	for _, override := range overrides {
+		found := false
+		for _, job := range jobs {
+			if _, ok := job.overrideInput(override); ok {
+				found = true
+			}
+		}
+
+		if found {
+			continue
+		}
+		if override.Input == "" {
+			return fmt.Errorf("%s: there is no input %d", override, override.Position)
+		}
+
+		return fmt.Errorf("%s: %s is not one of the inputs", override, override.Input)
+	}
+
+	return nil
+}
+
+// jobLanguageOverrides returns the overrides for the given job, given by the
+// file name of its inputs, as overrides only apply to the jobs using their file
+// and positions refer to the arguments, not to the expanded job inputs
+func jobLanguageOverrides(overrides []models.LanguageOverride, job remuxJob) (result []models.LanguageOverride) {
+	for _, override := range overrides {
+		if input, ok := job.overrideInput(override); ok {
+			override.Input = input
+			override.Position = 0
+			result = append(result, override)
+		}
+	}
+
+	return
+}
+
+// overrideInput returns the job input the override refers to. Positions refer
+// to the first file of that argument, so directories, glob patterns and
+// sidecar files do not shift them.
+func (job remuxJob) overrideInput(override models.LanguageOverride) (string, bool) {
+	if override.Input != "" {
+		return findFile(job.inputs, override.Input)
+	}
+
+	for i, argument := range job.arguments {
+		if argument == override.Position {
+			return job.inputs[i], true
+		}
+	}
+
+	return "", false
+}
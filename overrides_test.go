@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/elboletaire/remuxing/models"
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestParseLanguageOverride(t *testing.T) {
+	cases := map[string]models.LanguageOverride{
+		"input2.mkv:1=spa":    {Input: "input2.mkv", Track: 1, Language: "spa"},
+		"2:1=es-419":          {Position: 2, Track: 1, Language: "es-419"},
+		"C:\\movie.mkv:3=eng": {Input: "C:\\movie.mkv", Track: 3, Language: "eng"},
+		"dir/a:b.mkv:0=ger":   {Input: "dir/a:b.mkv", Track: 0, Language: "ger"},
+	}
+
+	for value, expected := range cases {
+		override, err := parseLanguageOverride(value)
+		tests.Ok(t, err)
+		tests.Equals(t, expected, override)
+	}
+
+	for _, value := range []string{"input.mkv=spa", "input.mkv:1", "input.mkv:a=spa", ":1=spa", "0:1=spa"} {
+		_, err := parseLanguageOverride(value)
+		tests.Assert(t, err != nil, "%q should not be a valid override", value)
+	}
+}
+
+func TestJobLanguageOverridesUseTheArgumentsPositions(t *testing.T) {
+	job := remuxJob{
+		inputs:    []string{"Movie.mkv", "Movie.spa.srt", "dir/Movie.S01E01.mka", "dub.mka"},
+		arguments: []int{1, 0, 2, 3},
+	}
+	overrides := []models.LanguageOverride{
+		{Position: 3, Track: 0, Language: "spa"},
+		{Input: "Movie.mkv", Track: 1, Language: "eng"},
+		{Position: 4, Track: 0, Language: "cat"},
+	}
+
+	tests.Equals(t, []models.LanguageOverride{
+		{Input: "dub.mka", Track: 0, Language: "spa"},
+		{Input: "Movie.mkv", Track: 1, Language: "eng"},
+	}, jobLanguageOverrides(overrides, job))
+
+	tests.Ok(t, checkLanguageOverrides(overrides[:2], []remuxJob{job}))
+	tests.Equals(
+		t,
+		"4:0=cat: there is no input 4",
+		checkLanguageOverrides(overrides, []remuxJob{job}).Error(),
+	)
+}
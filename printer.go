@@ -44,7 +44,26 @@ func printCommand(command []string) {
 
 func syntaxError(err string) {
 	fmt.Println(fmt.Sprintf("syntax error: %s", err))
-	os.Exit(1)
+	os.Exit(exitError)
+}
+
+func fatal(err error) {
+	printError(err)
+	os.Exit(exitCode(err))
+}
+
+func printError(err error) {
+	fmt.Fprintln(
+		colorable.NewColorableStderr(),
+		aurora.Red(fmt.Sprintf("error: %s", err)).String(),
+	)
+}
+
+func warning(text string) {
+	fmt.Fprintln(
+		colorable.NewColorableStderr(),
+		aurora.Yellow(fmt.Sprintf("warning: %s", text)).String(),
+	)
 }
 
 func title(text string) {
@@ -59,6 +78,86 @@ func title(text string) {
 	)
 }
 
+func printInputs(inputs []*models.Info) {
+	title("INPUTS")
+	for _, input := range inputs {
+		properties := input.Container.Properties
+		fmt.Fprintf(
+			colorable.NewColorableStdout(),
+			aurora.Green("- %s (%s) %q\n").String(),
+			input.FileName,
+			input.GetDuration(),
+			properties.Title,
+		)
+		fmt.Fprintf(
+			colorable.NewColorableStdout(),
+			aurora.Gray(gray, "  %d chapter editions, %d attachments (%d fonts), %d global tags\n").String(),
+			input.GetChapterEditions(),
+			len(input.Attachments),
+			len(input.GetFonts()),
+			input.GetGlobalTags(),
+		)
+		if sync := input.Sync; sync != nil && sync.FrameRate != nil {
+			numerator, denominator := sync.GetFactor()
+			fmt.Fprintf(
+				colorable.NewColorableStdout(),
+				aurora.Gray(gray, "  runs at %s instead of %s, stretched by %d/%d\n").String(),
+				sync.FrameRate,
+				sync.VideoFrameRate,
+				numerator,
+				denominator,
+			)
+		}
+		if sync := input.Sync; sync != nil && sync.Confidence > 0 {
+			fmt.Fprintf(
+				colorable.NewColorableStdout(),
+				aurora.Gray(gray, "  synced by %s (%.0f%% confidence)\n").String(),
+				sync.Offset,
+				sync.Confidence*100,
+			)
+		}
+	}
+}
+
+func printVideos(scores []models.VideoScore) {
+	title("VIDEOS")
+	for i, score := range scores {
+		video := score.Video
+		if i == 0 {
+			printTrack(&video)
+		} else {
+			fmt.Fprintf(
+				colorable.NewColorableStdout(),
+				aurora.Gray(gray, "- Track ID %d (%s) from file %s, discarded\n").String(),
+				video.Track.ID,
+				video.Track.Codec,
+				video.Input.FileName,
+			)
+		}
+
+		width, height := video.Track.GetResolution()
+		fmt.Fprintf(
+			colorable.NewColorableStdout(),
+			aurora.Gray(
+				gray,
+				"  score %.2f: %dx%d (%.2f), codec (%.2f), %d bits (%.2f), HDR %q (%.2f), %d kb/s (%.2f), position (%.2f)\n",
+			).String(),
+			score.Score,
+			width,
+			height,
+			score.Resolution,
+			score.Codec,
+			video.Track.GetBitDepth(),
+			score.BitDepth,
+			video.Track.GetHDR(),
+			score.HDR,
+			video.Track.GetBitrate()/1000,
+			score.Bitrate,
+			score.Position,
+		)
+	}
+}
+
 func printTracks(text string, tracks models.Tracks) {
 	title(text)
 	for _, track := range tracks {
@@ -75,4 +174,110 @@ func printTrack(track *models.TrackController) {
 		track.Track.Properties.Language,
 		track.Input.FileName,
 	)
+
+	if kind := track.Track.Kind(); kind != models.KindMain {
+		fmt.Fprintf(
+			colorable.NewColorableStdout(),
+			aurora.Gray(gray, "  %s track\n").String(),
+			kind,
+		)
+	}
+
+	if track.Track.ForcedDetected {
+		fmt.Fprintf(
+			colorable.NewColorableStdout(),
+			aurora.Gray(gray, "  flagged as forced, as it only has %d events\n").String(),
+			track.Track.GetFrames(),
+		)
+	}
+
+	if inference := track.Track.Inference; inference != nil {
+		fmt.Fprintf(
+			colorable.NewColorableStdout(),
+			aurora.Gray(gray, "  language inferred from its %s (%.0f%% confidence)\n").String(),
+			inference.Source,
+			inference.Confidence*100,
+		)
+	}
+}
+
+func printDecisions(decisions []models.Decision) {
+	title("EXPLAIN")
+	for _, decision := range decisions {
+		text := fmt.Sprintf(
+			"%s input %d, track %-3d %-9s %-18s %-4s %-11s %s\n",
+			mark(decision.Selected),
+			decision.Position,
+			decision.Track,
+			decision.Type,
+			decision.Codec,
+			decision.Language,
+			decision.Kind,
+			decision.Reason,
+		)
+
+		color := aurora.Gray(gray, text)
+		if decision.Selected {
+			color = aurora.Green(text)
+		}
+		fmt.Fprint(colorable.NewColorableStdout(), color.String())
+	}
+}
+
+func mark(selected bool) string {
+	if selected {
+		return "+"
+	}
+
+	return "-"
+}
+
+func printPicker(p *picker) {
+	title("TRACKS")
+	number := 0
+	for i := range p.groups {
+		group := &p.groups[i]
+		fmt.Fprintln(colorable.NewColorableStdout(), aurora.Yellow(group.title).String())
+
+		for index, entry := range group.entries {
+			number++
+			track := entry.track.Track
+
+			check := " "
+			if entry.selected {
+				check = "x"
+			}
+
+			text := fmt.Sprintf(
+				"[%s] %2d. Track ID %d (%s in %s) from file %s",
+				check,
+				number,
+				track.ID,
+				track.Codec,
+				track.GetLanguage(),
+				entry.track.Input.FileName,
+			)
+			if p.isDefault(group, index) {
+				text += ", default"
+			}
+			if track.Properties.Forced {
+				text += ", forced"
+			}
+			if track.OutputName != "" {
+				text += fmt.Sprintf(", named %q", track.OutputName)
+			}
+
+			color := aurora.Gray(gray, text)
+			if entry.selected {
+				color = aurora.Green(text)
+			}
+			fmt.Fprintln(colorable.NewColorableStdout(), color.String())
+		}
+	}
+
+	fmt.Fprintln(colorable.NewColorableStdout(), aurora.Gray(gray, "\nType h for help").String())
+}
+
+func prompt() {
+	fmt.Fprint(colorable.NewColorableStdout(), aurora.Yellow("> ").String())
 }
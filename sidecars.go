@@ -0,0 +1,180 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/elboletaire/remuxing/models"
+)
+
+// Extensions of the inputs whose sidecar files are looked for
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mk3d": true, ".webm": true, ".mp4": true, ".m4v": true,
+	".mov": true, ".avi": true, ".ts": true, ".m2ts": true, ".mpg": true,
+	".vob": true,
+}
+
+// Extensions of the subtitle and audio files discovered next to the videos.
+// VobSub .sub files are not included, as mkvmerge reads them through their .idx
+var sidecarExtensions = map[string]bool{
+	".srt": true, ".ass": true, ".ssa": true, ".vtt": true, ".sup": true,
+	".idx": true, ".mks": true,
+	".ac3": true, ".eac3": true, ".dts": true, ".thd": true, ".flac": true,
+	".aac": true, ".opus": true, ".mka": true, ".mp3": true, ".wav": true,
+}
+
+var (
+	forcedTokens          = map[string]bool{"forced": true, "forzados": true, "forzado": true, "foreign": true}
+	hearingImpairedTokens = map[string]bool{"sdh": true, "cc": true}
+)
+
+// sidecarHint is the track information inferred from a sidecar file name,
+// like Movie.en.forced.srt
+type sidecarHint struct {
+	language        string
+	ietf            string
+	forced          bool
+	hearingImpaired bool
+}
+
+// addSidecars adds the subtitle and audio files found next to the job video
+// inputs, right after them, keeping the hints inferred from their names.
+func addSidecars(job *remuxJob) {
+	job.sidecars = map[string]sidecarHint{}
+
+	var inputs []string
+	var arguments []int
+	for i, input := range job.inputs {
+		inputs = append(inputs, input)
+		arguments = append(arguments, job.arguments[i])
+
+		for _, sidecar := range findSidecars(input) {
+			// Already given (or discovered), hints are set to it anyway
+			if path, ok := findFile(job.inputs, sidecar.path); ok {
+				job.sidecars[path] = sidecar.hint
+				continue
+			}
+			if path, ok := findFile(inputs, sidecar.path); ok {
+				job.sidecars[path] = sidecar.hint
+				continue
+			}
+
+			job.sidecars[sidecar.path] = sidecar.hint
+			inputs = append(inputs, sidecar.path)
+			arguments = append(arguments, 0)
+		}
+	}
+
+	job.inputs = inputs
+	job.arguments = arguments
+}
+
+type sidecar struct {
+	path string
+	hint sidecarHint
+}
+
+// findSidecars returns the files sharing the base name of the given video,
+// like Movie.spa.srt or Movie.cat.ac3 for Movie.mkv
+func findSidecars(video string) (sidecars []sidecar) {
+	ext := filepath.Ext(video)
+	if !videoExtensions[strings.ToLower(ext)] {
+		return
+	}
+
+	dir := filepath.Dir(video)
+	base := strings.TrimSuffix(filepath.Base(video), ext)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		sidecarExt := filepath.Ext(name)
+		if file.IsDir() || !sidecarExtensions[strings.ToLower(sidecarExt)] {
+			continue
+		}
+
+		stem := strings.TrimSuffix(name, sidecarExt)
+		if stem != base && !strings.HasPrefix(stem, base+".") {
+			continue
+		}
+
+		tokens := strings.Split(strings.TrimPrefix(stem, base), ".")
+		sidecars = append(sidecars, sidecar{
+			path: filepath.Join(dir, name),
+			hint: parseSidecarTokens(tokens),
+		})
+	}
+
+	return
+}
+
+// parseSidecarTokens infers the language and flags from the file name tokens
+// found between the video base name and the extension
+func parseSidecarTokens(tokens []string) (hint sidecarHint) {
+	for _, token := range tokens {
+		lower := strings.ToLower(token)
+
+		switch {
+		case lower == "":
+		case forcedTokens[lower]:
+			hint.forced = true
+		case hearingImpairedTokens[lower]:
+			hint.hearingImpaired = true
+		// Hindi, unless the language was already given, like Movie.en.hi.srt
+		case lower == "hi" && hint.language != "":
+			hint.hearingImpaired = true
+		case hint.language == "":
+			hint.language = models.LanguageFromToken(lower)
+			// pt-BR, es_419...
+			if i := strings.IndexAny(lower, "-_"); hint.language == "" && i > 0 {
+				hint.language = models.LanguageFromToken(lower[:i])
+				if hint.language != "" {
+					hint.ietf = strings.Replace(token, "_", "-", -1)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// applySidecarHints sets the language and flags inferred from the sidecar
+// file names to their tracks. Languages already set by the files are kept.
+func applySidecarHints(tracks models.TracksController, hints map[string]sidecarHint) {
+	for _, input := range tracks.Inputs {
+		hint, ok := hints[input.FileName]
+		if !ok {
+			continue
+		}
+
+		for _, track := range input.Tracks {
+			properties := &track.Track.Properties
+			if hint.language != "" && track.Track.HasUndefinedLanguage() {
+				properties.Language = hint.language
+				properties.LanguageIETF = hint.ietf
+			}
+			if hint.forced {
+				properties.Forced = true
+			}
+			if hint.hearingImpaired {
+				properties.HearingImpaired = true
+			}
+		}
+	}
+}
+
+// findFile returns the path used in files to refer to the given file
+func findFile(files []string, file string) (string, bool) {
+	for _, f := range files {
+		if models.SameFile(f, file) {
+			return f, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elboletaire/remuxing/models"
+	"github.com/elboletaire/remuxing/tests"
+)
+
+func TestParseSidecarTokens(t *testing.T) {
+	cases := map[string]sidecarHint{
+		".spa":           {language: "spa"},
+		".en.forced":     {language: "eng", forced: true},
+		".eng.sdh":       {language: "eng", hearingImpaired: true},
+		".Castellano":    {language: "spa"},
+		".pt-BR":         {language: "por", ietf: "pt-BR"},
+		".hi":            {language: "hin"},
+		".en.hi":         {language: "eng", hearingImpaired: true},
+		".1080p.unknown": {},
+		"":               {},
+	}
+
+	for suffix, expected := range cases {
+		tests.Equals(t, expected, parseSidecarTokens(strings.Split(suffix, ".")))
+	}
+}
+
+func TestAddSidecarsDiscoversFilesNextToTheVideos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remuxing")
+	tests.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	movie := filepath.Join(dir, "Movie.mkv")
+	dub := filepath.Join(dir, "dub", "Movie.mka")
+	touch(t,
+		movie,
+		dub,
+		filepath.Join(dir, "Movie.spa.srt"),
+		filepath.Join(dir, "Movie.en.forced.ass"),
+		filepath.Join(dir, "Movie.idx"),
+		filepath.Join(dir, "Movie.sub"),
+		filepath.Join(dir, "Movie.cat.ac3"),
+		filepath.Join(dir, "Movie 2.spa.srt"),
+		filepath.Join(dir, "Other.eng.srt"),
+	)
+
+	job := remuxJob{inputs: []string{movie, filepath.Join(dir, "Movie.cat.ac3"), dub}, arguments: []int{1, 2, 3}}
+	addSidecars(&job)
+
+	tests.Equals(t, []string{
+		movie,
+		filepath.Join(dir, "Movie.en.forced.ass"),
+		filepath.Join(dir, "Movie.idx"),
+		filepath.Join(dir, "Movie.spa.srt"),
+		filepath.Join(dir, "Movie.cat.ac3"),
+		dub,
+	}, job.inputs)
+	tests.Equals(t, []int{1, 0, 0, 0, 2, 3}, job.arguments)
+
+	tests.Equals(t, map[string]sidecarHint{
+		filepath.Join(dir, "Movie.en.forced.ass"): {language: "eng", forced: true},
+		filepath.Join(dir, "Movie.idx"):           {},
+		filepath.Join(dir, "Movie.spa.srt"):       {language: "spa"},
+		filepath.Join(dir, "Movie.cat.ac3"):       {language: "cat"},
+	}, job.sidecars)
+}
+
+func TestApplySidecarHintsKeepsKnownLanguages(t *testing.T) {
+	srt := &models.Info{FileName: "Movie.spa.sdh.srt"}
+	srt.Tracks = models.Tracks{{Input: srt, Track: &models.Track{Type: "subtitles"}}}
+	srt.Tracks[0].Track.Properties.Language = "und"
+
+	mka := &models.Info{FileName: "Movie.spa.mka"}
+	mka.Tracks = models.Tracks{{Input: mka, Track: &models.Track{Type: "audio"}}}
+	mka.Tracks[0].Track.Properties.Language = "cat"
+
+	applySidecarHints(models.TracksController{Inputs: []*models.Info{srt, mka}}, map[string]sidecarHint{
+		"Movie.spa.sdh.srt": {language: "spa", hearingImpaired: true},
+		"Movie.spa.mka":     {language: "spa"},
+	})
+
+	tests.Equals(t, "spa", srt.Tracks[0].Track.Properties.Language)
+	tests.Assert(t, srt.Tracks[0].Track.Properties.HearingImpaired, "hearing impaired flag should be set")
+	tests.Equals(t, "cat", mka.Tracks[0].Track.Properties.Language)
+}